@@ -15,6 +15,15 @@ func Copy(source context.Context, keys ...any) context.Context {
 		ctx = context.WithValue(ctx, txKey, transactionValue)
 	}
 
+	if sourceFields := logger.ContextFields(source); len(sourceFields) > 0 {
+		fields := make([]logger.Field, 0, len(sourceFields))
+		for k, v := range sourceFields {
+			fields = append(fields, logger.Any(k, v))
+		}
+
+		ctx = logger.WithFields(ctx, fields...)
+	}
+
 	for _, key := range keys {
 		ctx = context.WithValue(ctx, key, source.Value(key))
 	}
@@ -10,8 +10,8 @@ import (
 )
 
 func FuzzCopy(f *testing.F) {
-	testKeys := []any{"Key1", "2", string(logger.Settings.TransactionKey)}
-	testValues := []any{"1", "Value2", "123"}
+	testKeys := []string{"Key1", "2", string(logger.Settings.TransactionKey)}
+	testValues := []string{"1", "Value2", "123"}
 
 	for i := range testKeys {
 		f.Add(testKeys[i], testValues[i])
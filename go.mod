@@ -1,13 +1,16 @@
 module github.com/mikarios/golib
 
-go 1.18
+go 1.21
 
 require (
-	github.com/google/uuid v1.3.0
+	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.0
 	github.com/mxschmitt/golang-combinations v1.1.0
+	github.com/oklog/ulid/v2 v2.1.1
 	github.com/sirupsen/logrus v1.8.1
 	github.com/streadway/amqp v1.0.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
 )
 
 require golang.org/x/sys v0.1.0 // indirect
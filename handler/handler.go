@@ -16,6 +16,15 @@ var (
 	ErrConversion    = errors.New("failed to convert to requested type")
 )
 
+// Sentinels returns the errors of the package by the names they are logged with. The logger package registers them,
+// so logged errors wrapping them list their names without any setup.
+func Sentinels() map[string]error {
+	return map[string]error{
+		"handler.ErrParamNotFound": ErrParamNotFound,
+		"handler.ErrConversion":    ErrConversion,
+	}
+}
+
 type parameters interface {
 	map[string]string | map[string][]string | url.Values
 }
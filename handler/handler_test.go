@@ -977,3 +977,16 @@ func TestGetQueryParamBool(t *testing.T) {
 		})
 	}
 }
+
+func TestSentinels(t *testing.T) {
+	t.Parallel()
+
+	want := map[string]error{
+		"handler.ErrParamNotFound": handler.ErrParamNotFound,
+		"handler.ErrConversion":    handler.ErrConversion,
+	}
+
+	if got := handler.Sentinels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sentinels() = %v, want %v", got, want)
+	}
+}
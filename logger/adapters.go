@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Write logs p at the level of the exportedLogger, without its trailing new line, so that the exportedLogger can be
+// the output of a *log.Logger. Every call is one entry.
+func (expLogger *exportedLogger) Write(p []byte) (int, error) {
+	expLogger.logAt(context.Background(), expLogger.level, string(bytes.TrimRight(p, "\r\n")))
+
+	return len(p), nil
+}
+
+// StdLogger returns a *log.Logger that writes through the exportedLogger, e.g. for http.Server.ErrorLog.
+func (expLogger *exportedLogger) StdLogger() *log.Logger {
+	return log.New(expLogger, "", 0)
+}
+
+// Print logs the message at the level of the exportedLogger, formatted like fmt.Sprint.
+func (expLogger *exportedLogger) Print(v ...interface{}) {
+	expLogger.logAt(context.Background(), expLogger.level, fmt.Sprint(v...))
+}
+
+// Println logs the message at the level of the exportedLogger, formatted like fmt.Sprintln without the new line.
+func (expLogger *exportedLogger) Println(v ...interface{}) {
+	msg := fmt.Sprintln(v...)
+	expLogger.logAt(context.Background(), expLogger.level, msg[:len(msg)-1])
+}
+
+// Tracef logs the formatted message at trace level.
+func (expLogger *exportedLogger) Tracef(format string, v ...interface{}) {
+	expLogger.logAt(context.Background(), LogLevels.TRACE, fmt.Sprintf(format, v...))
+}
+
+// Debugf logs the formatted message at debug level.
+func (expLogger *exportedLogger) Debugf(format string, v ...interface{}) {
+	expLogger.logAt(context.Background(), LogLevels.DEBUG, fmt.Sprintf(format, v...))
+}
+
+// Infof logs the formatted message at info level.
+func (expLogger *exportedLogger) Infof(format string, v ...interface{}) {
+	expLogger.logAt(context.Background(), LogLevels.INFO, fmt.Sprintf(format, v...))
+}
+
+// Warnf logs the formatted message at warning level.
+func (expLogger *exportedLogger) Warnf(format string, v ...interface{}) {
+	expLogger.logAt(context.Background(), LogLevels.WARNING, fmt.Sprintf(format, v...))
+}
+
+// Errorf logs the formatted message at error level, using it as the error of the entry.
+func (expLogger *exportedLogger) Errorf(format string, v ...interface{}) {
+	expLogger.logAt(context.Background(), LogLevels.ERROR, fmt.Sprintf(format, v...))
+}
+
+// Info logs msg at info level with the transaction of ctx. Together with Warn, Error and Trace it matches the
+// context-aware loggers of sql libraries such as gorm, which only need a LogMode method on top.
+func (expLogger *exportedLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	expLogger.logAt(ctx, LogLevels.INFO, fmt.Sprintf(msg, data...))
+}
+
+// Warn logs msg at warning level with the transaction of ctx.
+func (expLogger *exportedLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	expLogger.logAt(ctx, LogLevels.WARNING, fmt.Sprintf(msg, data...))
+}
+
+// Error logs msg at error level with the transaction of ctx.
+func (expLogger *exportedLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	expLogger.logAt(ctx, LogLevels.ERROR, fmt.Sprintf(msg, data...))
+}
+
+// Trace logs a query at the level of the exportedLogger, or at error level if it failed, with the sql, the rows it
+// affected and how long it took since begin.
+func (expLogger *exportedLogger) Trace(
+	ctx context.Context,
+	begin time.Time,
+	fc func() (sql string, rowsAffected int64),
+	err error,
+) {
+	elapsed := time.Since(begin)
+	sql, rows := fc()
+	fields := []interface{}{String("sql", sql), Int64("rows", rows), Duration("elapsed", elapsed)}
+
+	if err != nil {
+		l := expLogger.target()
+		l.Error(expLogger.context(ctx, l), err, append([]interface{}{"query failed"}, fields...)...)
+
+		return
+	}
+
+	expLogger.logAt(ctx, expLogger.level, "query", fields...)
+}
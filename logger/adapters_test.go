@@ -0,0 +1,128 @@
+// nolint:testpackage // decodes the lines with decodeLine and decodeLines of the other internal tests.
+package logger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// leveledLogger is the kind of interface third-party libraries accept.
+type leveledLogger interface {
+	Print(v ...interface{})
+	Debugf(format string, v ...interface{})
+	Infof(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// sqlLogger is the context-aware interface of sql libraries such as gorm, without LogMode.
+type sqlLogger interface {
+	Info(ctx context.Context, msg string, data ...interface{})
+	Warn(ctx context.Context, msg string, data ...interface{})
+	Error(ctx context.Context, msg string, data ...interface{})
+	Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error)
+}
+
+func TestStdLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+
+	server := &http.Server{ErrorLog: l.NewLogger("http", LogLevels.WARNING).StdLogger()} // nolint:gosec // not served.
+	server.ErrorLog.Printf("http: TLS handshake error from %s", "1.2.3.4")
+
+	line := decodeLine(t, &buf)
+	messages, _ := line["message"].([]interface{})
+
+	if line["level"] != "warning" ||
+		line["identifier"] != "http" ||
+		len(messages) != 1 ||
+		messages[0] != "http: TLS handshake error from 1.2.3.4" {
+		t.Error("unexpected line", line)
+	}
+}
+
+func TestLeveledLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+
+	var leveled leveledLogger = l.NewLogger("worker", LogLevels.INFO)
+
+	leveled.Print("started ", 3, " workers")
+	leveled.Debugf("job %d", 1)
+	leveled.Errorf("job %d failed", 2)
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 3 {
+		t.Fatal("expected 3 lines, got", lines)
+	}
+
+	expected := []struct {
+		level string
+		msg   string
+	}{
+		{level: "info", msg: ""},
+		{level: "debug", msg: ""},
+		{level: "error", msg: "job 2 failed"},
+	}
+
+	for i, line := range lines {
+		if line["level"] != expected[i].level || line["msg"] != expected[i].msg || line["identifier"] != "worker" {
+			t.Error("unexpected line", i, line)
+		}
+	}
+
+	if messages, _ := lines[0]["message"].([]interface{}); len(messages) != 1 || messages[0] != "started 3 workers" {
+		t.Error("unexpected Print message", lines[0]["message"])
+	}
+}
+
+func TestSQLLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+
+	var sql sqlLogger = l.NewLogger("db", LogLevels.DEBUG)
+
+	ctx := context.WithValue(context.TODO(), Settings.TransactionKey, "abc")
+	query := func() (string, int64) { return "SELECT 1", 1 }
+
+	sql.Trace(ctx, time.Now(), query, nil)
+	sql.Trace(ctx, time.Now(), query, errText)
+	sql.Warn(ctx, "slow query %s", "SELECT 1")
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 3 {
+		t.Fatal("expected 3 lines, got", lines)
+	}
+
+	for _, line := range lines {
+		if line["txID"] != "abc" || line["identifier"] != "db" {
+			t.Error("expected transaction and identifier, got", line)
+		}
+	}
+
+	if lines[0]["level"] != "debug" || lines[0]["sql"] != "SELECT 1" || lines[0]["rows"] != float64(1) {
+		t.Error("unexpected query line", lines[0])
+	}
+
+	if lines[1]["level"] != "error" || lines[1]["error"] != errText.Error() || lines[1]["sql"] != "SELECT 1" {
+		t.Error("unexpected failed query line", lines[1])
+	}
+
+	if lines[2]["level"] != "warning" {
+		t.Error("unexpected warn line", lines[2])
+	}
+}
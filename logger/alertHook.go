@@ -0,0 +1,255 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultAlertBatchSize  = 20
+	defaultAlertInterval   = time.Second
+	defaultAlertQueueSize  = 1024
+	defaultAlertMaxRetries = 3
+	defaultAlertBackoff    = 100 * time.Millisecond
+	defaultAlertTimeout    = 10 * time.Second
+)
+
+// ErrHookClosed is returned when flushing a hook that is closed.
+var ErrHookClosed = errors.New("hook is closed")
+
+// Alerter sends entries to an alerting backend, see WebhookAlerter.
+type Alerter interface {
+	Alert(ctx context.Context, entries []Entry) error
+}
+
+// AlertHookConfig configures an AlertHook. Zero values are replaced with the defaults.
+type AlertHookConfig struct {
+	// Levels are the levels of the entries sent, PANIC, FATAL and ERROR by default.
+	Levels []LogLevel
+	// BatchSize is the most entries sent with one call of the Alerter, 20 by default.
+	BatchSize int
+	// Interval is how long an entry waits for its batch to fill before it is sent anyway, a second by default.
+	Interval time.Duration
+	// QueueSize is how many entries wait to be sent before new ones are dropped, 1024 by default.
+	QueueSize int
+	// MaxRetries is how many times a failed batch is sent again before it is given up, 3 by default.
+	MaxRetries int
+	// Backoff is the wait before the first retry, doubled on every next one, 100ms by default.
+	Backoff time.Duration
+	// Timeout limits every call of the Alerter, 10s by default. Fatal entries wait this long to be sent before the
+	// logger exits.
+	Timeout time.Duration
+}
+
+// AlertHookStats counts the entries of an AlertHook.
+type AlertHookStats struct {
+	// Sent counts the entries the Alerter accepted.
+	Sent uint64
+	// Failed counts the entries of batches given up after their retries.
+	Failed uint64
+	// Dropped counts the entries that found the queue full or the hook closed.
+	Dropped uint64
+}
+
+// AlertHook is a Hook that sends entries to an Alerter in batches from its own goroutine, so logging never waits for
+// the alerting backend, apart from fatal entries which are sent before the logger exits.
+type AlertHook struct {
+	alerter   Alerter
+	cfg       AlertHookConfig
+	queue     chan Entry
+	flush     chan chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	sent      atomic.Uint64
+	failed    atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// NewAlertHook creates an AlertHook sending to alerter and starts its goroutine. Add it to a logger with AddHook and
+// stop it with Close, or with the Close of the logger.
+func NewAlertHook(alerter Alerter, cfg AlertHookConfig) *AlertHook {
+	if len(cfg.Levels) == 0 {
+		cfg.Levels = []LogLevel{LogLevels.PANIC, LogLevels.FATAL, LogLevels.ERROR}
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultAlertBatchSize
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultAlertInterval
+	}
+
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultAlertQueueSize
+	}
+
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = defaultAlertMaxRetries
+	}
+
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultAlertBackoff
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultAlertTimeout
+	}
+
+	h := &AlertHook{
+		alerter: alerter,
+		cfg:     cfg,
+		queue:   make(chan Entry, cfg.QueueSize),
+		flush:   make(chan chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go h.run()
+
+	return h
+}
+
+// Levels returns the levels of the config.
+func (h *AlertHook) Levels() []LogLevel {
+	return h.cfg.Levels
+}
+
+// Fire queues entry, dropping it if the queue is full. Fatal entries are sent before Fire returns.
+func (h *AlertHook) Fire(_ context.Context, entry Entry) {
+	select {
+	case <-h.stop:
+		h.dropped.Add(1)
+		return
+	default:
+	}
+
+	select {
+	case h.queue <- entry:
+	default:
+		h.dropped.Add(1)
+		return
+	}
+
+	if entry.Level == LogLevels.FATAL {
+		ctx, cancel := context.WithTimeout(context.Background(), h.cfg.Timeout)
+		defer cancel()
+
+		_ = h.Flush(ctx)
+	}
+}
+
+// Flush waits until the entries queued so far are sent or given up.
+func (h *AlertHook) Flush(ctx context.Context) error {
+	ack := make(chan struct{})
+
+	select {
+	case h.flush <- ack:
+	case <-h.done:
+		return ErrHookClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close sends the queued entries and stops the goroutine of the hook. Entries fired afterwards are dropped.
+func (h *AlertHook) Close() error {
+	h.closeOnce.Do(func() {
+		close(h.stop)
+	})
+
+	<-h.done
+
+	return nil
+}
+
+// Stats returns the counters of the hook.
+func (h *AlertHook) Stats() AlertHookStats {
+	return AlertHookStats{
+		Sent:    h.sent.Load(),
+		Failed:  h.failed.Load(),
+		Dropped: h.dropped.Load(),
+	}
+}
+
+func (h *AlertHook) run() {
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.cfg.Interval)
+	defer ticker.Stop()
+
+	batch := make([]Entry, 0, h.cfg.BatchSize)
+
+	for {
+		select {
+		case entry := <-h.queue:
+			if batch = append(batch, entry); len(batch) >= h.cfg.BatchSize {
+				batch = h.send(batch)
+			}
+		case <-ticker.C:
+			batch = h.send(batch)
+		case ack := <-h.flush:
+			batch = h.drain(batch)
+			close(ack)
+		case <-h.stop:
+			h.drain(batch)
+			return
+		}
+	}
+}
+
+// drain sends batch together with every queued entry.
+func (h *AlertHook) drain(batch []Entry) []Entry {
+	for {
+		select {
+		case entry := <-h.queue:
+			if batch = append(batch, entry); len(batch) >= h.cfg.BatchSize {
+				batch = h.send(batch)
+			}
+		default:
+			return h.send(batch)
+		}
+	}
+}
+
+// send sends batch, retrying with backoff, and returns a new empty batch.
+func (h *AlertHook) send(batch []Entry) []Entry {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	backoff := h.cfg.Backoff
+
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), h.cfg.Timeout)
+		err := h.alerter.Alert(ctx, batch)
+
+		cancel()
+
+		if err == nil {
+			h.sent.Add(uint64(len(batch)))
+			break
+		}
+
+		if attempt == h.cfg.MaxRetries {
+			h.failed.Add(uint64(len(batch)))
+			break
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	return make([]Entry, 0, h.cfg.BatchSize)
+}
@@ -0,0 +1,163 @@
+// nolint:testpackage // replaces the ExitFunc of the logrus logger to log a fatal entry.
+package logger
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeAlerter struct {
+	batches  [][]Entry
+	failures int
+	block    chan struct{}
+	mu       sync.Mutex
+}
+
+func (a *fakeAlerter) Alert(_ context.Context, entries []Entry) error {
+	if a.block != nil {
+		<-a.block
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.failures > 0 {
+		a.failures--
+		return ErrAlertRejected
+	}
+
+	a.batches = append(a.batches, entries)
+
+	return nil
+}
+
+func (a *fakeAlerter) sent() [][]Entry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.batches
+}
+
+func TestAlertHookBatches(t *testing.T) {
+	t.Parallel()
+
+	alerter := &fakeAlerter{failures: 2}
+	h := NewAlertHook(alerter, AlertHookConfig{BatchSize: 2, Interval: time.Hour, Backoff: time.Millisecond})
+
+	l := New()
+	l.SetOutput(io.Discard)
+	l.AddHook(h)
+
+	ctx := context.WithValue(context.TODO(), Settings.TransactionKey, "abc")
+
+	l.Warning(ctx, "not alerted")
+	l.Error(ctx, errText, "first")
+	l.Error(ctx, errText, "second")
+	l.Error(ctx, errText, "third")
+
+	if err := l.Flush(context.TODO()); err != nil {
+		t.Fatal("could not flush", err)
+	}
+
+	batches := alerter.sent()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatal("expected a full batch and the rest after the flush, got:", batches)
+	}
+
+	if e := batches[0][0]; e.Message != "first" || e.TxID != "abc" || e.Level != LogLevels.ERROR {
+		t.Error("unexpected entry:", e)
+	}
+
+	if stats := h.Stats(); stats.Sent != 3 || stats.Failed != 0 || stats.Dropped != 0 {
+		t.Error("unexpected stats:", stats)
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal("could not close", err)
+	}
+
+	l.Error(ctx, errText, "after close")
+
+	if stats := h.Stats(); stats.Dropped != 1 {
+		t.Error("expected entries after close to be dropped, got:", stats)
+	}
+
+	if err := h.Flush(context.TODO()); err == nil {
+		t.Error("expected flushing a closed hook to fail")
+	}
+}
+
+func TestAlertHookGivesUp(t *testing.T) {
+	t.Parallel()
+
+	alerter := &fakeAlerter{failures: 10}
+	h := NewAlertHook(alerter, AlertHookConfig{MaxRetries: 2, Backoff: time.Millisecond})
+
+	h.Fire(context.TODO(), Entry{Level: LogLevels.ERROR})
+
+	if err := h.Close(); err != nil {
+		t.Fatal("could not close", err)
+	}
+
+	if stats := h.Stats(); stats.Failed != 1 || stats.Sent != 0 || alerter.failures != 7 {
+		t.Error("expected the entry to fail after 3 attempts, got:", stats, alerter.failures)
+	}
+}
+
+func TestAlertHookDoesNotBlock(t *testing.T) {
+	t.Parallel()
+
+	alerter := &fakeAlerter{block: make(chan struct{})}
+	h := NewAlertHook(alerter, AlertHookConfig{BatchSize: 1, QueueSize: 1})
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 10; i++ {
+			h.Fire(context.TODO(), Entry{Level: LogLevels.ERROR})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Fire not to wait for the alerter")
+	}
+
+	close(alerter.block)
+
+	if err := h.Close(); err != nil {
+		t.Fatal("could not close", err)
+	}
+
+	if stats := h.Stats(); stats.Dropped == 0 || stats.Sent+stats.Dropped != 10 {
+		t.Error("expected the entries that found the queue full to be dropped, got:", stats)
+	}
+}
+
+func TestAlertHookFatal(t *testing.T) {
+	t.Parallel()
+
+	alerter := &fakeAlerter{}
+	h := NewAlertHook(alerter, AlertHookConfig{Interval: time.Hour})
+
+	defer h.Close()
+
+	l := New()
+	l.SetOutput(io.Discard)
+	l.SetLogTrace(true)
+	l.log.ExitFunc = func(int) {}
+	l.AddHook(h)
+
+	l.Fatal(context.TODO(), errText)
+
+	batches := alerter.sent()
+	if len(batches) != 1 || batches[0][0].Level != LogLevels.FATAL || len(batches[0][0].Trace) == 0 {
+		t.Error("expected the fatal entry with its trace to be sent before exiting, got:", batches)
+	}
+}
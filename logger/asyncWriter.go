@@ -0,0 +1,287 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrWriterClosed is returned when writing to an AsyncWriter that has been closed.
+var ErrWriterClosed = errors.New("writer is closed")
+
+// OverflowPolicy decides what an AsyncWriter does when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes Write wait until there is room in the buffer.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the entry being written.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest entry in the buffer to make room for the one being written.
+	OverflowDropOldest
+)
+
+// AsyncWriterStats holds the counters of an AsyncWriter.
+type AsyncWriterStats struct {
+	Written uint64
+	Dropped uint64
+	Failed  uint64
+	Queued  int
+}
+
+// AsyncWriter writes to the underlying writer from a single goroutine, so that logging never waits for the output
+// unless OverflowBlock is used and the buffer is full. Every Write is kept as one entry, which matches the way the
+// logger writes one line per Write. Use Flush or Close before exiting, otherwise buffered entries are lost.
+type AsyncWriter struct {
+	out      io.Writer
+	ring     [][]byte
+	head     int
+	count    int
+	policy   OverflowPolicy
+	stats    AsyncWriterStats
+	queued   uint64
+	done     uint64
+	progress chan struct{}
+	wake     chan struct{}
+	stopped  chan struct{}
+	closed   bool
+	mu       sync.Mutex
+}
+
+// NewAsyncWriter creates an AsyncWriter that buffers up to capacity entries before applying policy. A capacity
+// lower than 1 is treated as 1.
+func NewAsyncWriter(out io.Writer, capacity int, policy OverflowPolicy) *AsyncWriter {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	w := &AsyncWriter{
+		out:      out,
+		ring:     make([][]byte, capacity),
+		policy:   policy,
+		progress: make(chan struct{}),
+		wake:     make(chan struct{}, 1),
+		stopped:  make(chan struct{}),
+	}
+
+	go w.run()
+
+	return w
+}
+
+// Write copies p to the buffer. It only returns an error if the writer is closed. Entries dropped because of the
+// overflow policy are reported as written and counted in the stats.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for !w.closed && w.count == len(w.ring) {
+		switch w.policy {
+		case OverflowDropNewest:
+			w.stats.Dropped++
+
+			return len(p), nil
+		case OverflowDropOldest:
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+			w.count--
+			w.done++
+			w.stats.Dropped++
+			w.notify()
+		default:
+			progress := w.progress
+			w.mu.Unlock()
+			<-progress
+			w.mu.Lock()
+		}
+	}
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	w.ring[(w.head+w.count)%len(w.ring)] = entry
+	w.count++
+	w.queued++
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+
+	return len(p), nil
+}
+
+// Flush waits until every entry written before the call has reached the underlying writer or has been dropped, or
+// until ctx is done.
+func (w *AsyncWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	target := w.queued
+
+	for w.done < target {
+		progress := w.progress
+		w.mu.Unlock()
+
+		select {
+		case <-progress:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		w.mu.Lock()
+	}
+
+	w.mu.Unlock()
+
+	return nil
+}
+
+// Close stops accepting entries and waits until the buffered ones reach the underlying writer. The underlying writer
+// is not closed. Use Flush first to bound the wait with a context.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		w.notify()
+
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+	w.mu.Unlock()
+
+	<-w.stopped
+
+	return nil
+}
+
+// Stats returns the counters of the writer.
+func (w *AsyncWriter) Stats() AsyncWriterStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := w.stats
+	stats.Queued = w.count
+
+	return stats
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.stopped)
+
+	for {
+		w.mu.Lock()
+
+		if w.count == 0 {
+			closed := w.closed
+			w.mu.Unlock()
+
+			if closed {
+				return
+			}
+
+			<-w.wake
+
+			continue
+		}
+
+		entry := w.ring[w.head]
+		w.ring[w.head] = nil
+		w.head = (w.head + 1) % len(w.ring)
+		w.count--
+		w.mu.Unlock()
+
+		_, err := w.out.Write(entry)
+
+		w.mu.Lock()
+		if err != nil {
+			w.stats.Failed++
+		} else {
+			w.stats.Written++
+		}
+
+		w.done++
+		w.notify()
+		w.mu.Unlock()
+	}
+}
+
+// notify wakes up everyone waiting for progress. It must be called with the lock held.
+func (w *AsyncWriter) notify() {
+	close(w.progress)
+	w.progress = make(chan struct{})
+}
+
+type flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Flush waits until the entries logged so far by the default logger reach its output. See Logger.Flush.
+func Flush(ctx context.Context) error {
+	return Default().Flush(ctx)
+}
+
+// Close flushes and closes the output of the default logger. See Logger.Close.
+func Close() error {
+	return Default().Close()
+}
+
+// Flush waits until the entries logged so far reach the outputs, sinks included, that buffer them like AsyncWriter
+// does, and are sent by hooks that batch them like AlertHook does. Outputs that write synchronously return
+// immediately.
+func (l *Logger) Flush(ctx context.Context) error {
+	for _, out := range l.outputs() {
+		if f, ok := out.(flusher); ok {
+			if err := f.Flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, h := range l.currentHooks() {
+		if f, ok := h.hook.(flusher); ok {
+			if err := f.Flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close stops sampling, logging its last summary, and closes the outputs, sinks included, that are AsyncWriters or
+// RotatingFiles, waiting for every buffered entry to be written. AlertHooks are closed last, after sending their
+// queued entries. The logger must not be used afterwards unless new outputs are set.
+func (l *Logger) Close() error {
+	if err := l.SetSampling(nil); err != nil {
+		return err
+	}
+
+	for _, out := range l.outputs() {
+		switch w := out.(type) {
+		case *AsyncWriter:
+			if err := w.Close(); err != nil {
+				return err
+			}
+		case *RotatingFile:
+			if err := w.Close(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, h := range l.currentHooks() {
+		if a, ok := h.hook.(*AlertHook); ok {
+			if err := a.Close(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mikarios/golib/logger"
+)
+
+// gatedWriter blocks every write until the gate is opened.
+type gatedWriter struct {
+	gate chan struct{}
+	buf  bytes.Buffer
+	mu   sync.Mutex
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{gate: make(chan struct{})}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.gate
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.String()
+}
+
+func waitQueued(t *testing.T, w *logger.AsyncWriter, queued int) {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		if w.Stats().Queued == queued {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatal("expected", queued, "queued entries, got", w.Stats().Queued)
+}
+
+func TestAsyncWriterDropPolicies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		policy   logger.OverflowPolicy
+		expected string
+	}{
+		{policy: logger.OverflowDropNewest, expected: "0\n1\n2\n"},
+		{policy: logger.OverflowDropOldest, expected: "0\n3\n4\n"},
+	}
+
+	for _, tt := range tests {
+		out := newGatedWriter()
+		w := logger.NewAsyncWriter(out, 2, tt.policy)
+
+		_, _ = w.Write([]byte("0\n"))
+		// Wait for the first entry to be picked up by the writing goroutine.
+		waitQueued(t, w, 0)
+
+		for _, entry := range []string{"1\n", "2\n", "3\n", "4\n"} {
+			if _, err := w.Write([]byte(entry)); err != nil {
+				t.Error("unexpected error", err)
+			}
+		}
+
+		close(out.gate)
+
+		if err := w.Flush(context.Background()); err != nil {
+			t.Error("unexpected flush error", err)
+		}
+
+		if out.String() != tt.expected {
+			t.Errorf("policy %v: expected %q, got %q", tt.policy, tt.expected, out.String())
+		}
+
+		if stats := w.Stats(); stats.Dropped != 2 || stats.Written != 3 || stats.Queued != 0 {
+			t.Errorf("policy %v: unexpected stats %+v", tt.policy, stats)
+		}
+
+		_ = w.Close()
+	}
+}
+
+func TestAsyncWriterBlock(t *testing.T) {
+	t.Parallel()
+
+	out := newGatedWriter()
+	w := logger.NewAsyncWriter(out, 1, logger.OverflowBlock)
+
+	_, _ = w.Write([]byte("0\n"))
+	waitQueued(t, w, 0)
+	_, _ = w.Write([]byte("1\n"))
+
+	written := make(chan struct{})
+
+	go func() {
+		_, _ = w.Write([]byte("2\n"))
+		close(written)
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("expected write to block while the buffer is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := w.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected flush to stop when the context is done, got", err)
+	}
+
+	close(out.gate)
+	<-written
+
+	if err := w.Close(); err != nil {
+		t.Error("unexpected close error", err)
+	}
+
+	if out.String() != "0\n1\n2\n" {
+		t.Errorf("expected every entry after close, got %q", out.String())
+	}
+
+	if _, err := w.Write([]byte("3\n")); !errors.Is(err, logger.ErrWriterClosed) {
+		t.Error("expected logger.ErrWriterClosed, got", err)
+	}
+}
+
+func TestLoggerFlush(t *testing.T) {
+	t.Parallel()
+
+	out := newGatedWriter()
+
+	l := logger.New()
+	l.SetOutput(logger.NewAsyncWriter(out, 10, logger.OverflowBlock))
+
+	for i := 0; i < 5; i++ {
+		l.Info(context.TODO(), "test", logger.Int("i", i))
+	}
+
+	close(out.gate)
+
+	if err := l.Flush(context.Background()); err != nil {
+		t.Error("unexpected flush error", err)
+	}
+
+	if strings.Count(out.String(), "\n") != 5 {
+		t.Error("expected every entry after flush, got", out.String())
+	}
+
+	if err := l.Close(); err != nil {
+		t.Error("unexpected close error", err)
+	}
+}
@@ -2,19 +2,37 @@ package logger
 
 type myKey string
 
-// Settings holds the settings for the logger.
-var Settings = struct {
-	TransactionKey myKey
-	LogInfoKey     myKey
-	MessageKey     myKey
-	ErrorKey       myKey
-	TraceKey       myKey
-	IdentifierKey  myKey
-}{
-	TransactionKey: "txID",
-	LogInfoKey:     "logInfo",
-	MessageKey:     "message",
-	ErrorKey:       "error",
-	TraceKey:       "trace",
-	IdentifierKey:  "identifier",
+// KeySettings holds the keys used to read values from the context and to name the fields of a log entry.
+// TraceIDKey and SpanIDKey name the fields of the OpenTelemetry span found in the context, leaving them empty stops
+// adding them. The error chain, sentinels, code and category keys name the fields describing logged errors, see
+// ErrorChain, RegisterSentinel, ErrorCoder and ErrorCategorizer. Leaving them empty stops adding them as well.
+type KeySettings struct {
+	TransactionKey    myKey
+	LogInfoKey        myKey
+	MessageKey        myKey
+	ErrorKey          myKey
+	TraceKey          myKey
+	IdentifierKey     myKey
+	TraceIDKey        myKey
+	SpanIDKey         myKey
+	ErrorChainKey     myKey
+	ErrorSentinelsKey myKey
+	ErrorCodeKey      myKey
+	ErrorCategoryKey  myKey
+}
+
+// Settings holds the settings for the logger. Loggers without their own KeySettings read these on every call.
+var Settings = KeySettings{
+	TransactionKey:    "txID",
+	LogInfoKey:        "logInfo",
+	MessageKey:        "message",
+	ErrorKey:          "error",
+	TraceKey:          "trace",
+	IdentifierKey:     "identifier",
+	TraceIDKey:        "trace_id",
+	SpanIDKey:         "span_id",
+	ErrorChainKey:     "error_chain",
+	ErrorSentinelsKey: "error_sentinels",
+	ErrorCodeKey:      "error_code",
+	ErrorCategoryKey:  "error_category",
 }
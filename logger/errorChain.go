@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mikarios/golib/handler"
+)
+
+// maxChainDepth limits how many wrapped errors are logged, in case of an error that wraps itself.
+const maxChainDepth = 32
+
+// ErrorCoder is implemented by errors that carry a machine readable code, which is logged in
+// KeySettings.ErrorCodeKey. The first error of the chain implementing it is used.
+type ErrorCoder interface {
+	ErrorCode() string
+}
+
+// ErrorCategorizer is implemented by errors that belong to a category, which is logged in
+// KeySettings.ErrorCategoryKey. The first error of the chain implementing it is used.
+type ErrorCategorizer interface {
+	ErrorCategory() string
+}
+
+// ChainLink describes one of the errors of an unwrap chain.
+type ChainLink struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type sentinel struct {
+	name string
+	err  error
+}
+
+var (
+	sentinelsMu sync.RWMutex
+	sentinels   []sentinel
+)
+
+func init() {
+	RegisterSentinel("logger.ErrInvalidFormatter", ErrInvalidFormatter)
+	RegisterSentinel("logger.ErrInvalidSink", ErrInvalidSink)
+	RegisterSentinel("logger.ErrInvalidSampling", ErrInvalidSampling)
+	RegisterSentinel("logger.ErrWriterClosed", ErrWriterClosed)
+	RegisterSentinel("logger.ErrInvalidRotatingFile", ErrInvalidRotatingFile)
+	RegisterSentinel("logger.ErrInvalidLevelRequest", ErrInvalidLevelRequest)
+	RegisterSentinel("logger.ErrHookClosed", ErrHookClosed)
+	RegisterSentinel("logger.ErrAlertRejected", ErrAlertRejected)
+
+	// The handler package does not depend on the logger, so its errors are registered from here.
+	RegisterSentinels(handler.Sentinels())
+}
+
+// RegisterSentinel gives a name to a sentinel error. Logged errors that match it with errors.Is list name in
+// KeySettings.ErrorSentinelsKey, so that entries can be grouped by cause instead of by message. Registering a name
+// again replaces its error.
+func RegisterSentinel(name string, err error) {
+	sentinelsMu.Lock()
+	defer sentinelsMu.Unlock()
+
+	for i := range sentinels {
+		if sentinels[i].name == name {
+			sentinels[i].err = err
+			return
+		}
+	}
+
+	sentinels = append(sentinels, sentinel{name: name, err: err})
+}
+
+// RegisterSentinels registers every error of errs under its name, in the order of the names.
+func RegisterSentinels(errs map[string]error) {
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		RegisterSentinel(name, errs[name])
+	}
+}
+
+// Sentinels returns the names of the registered sentinel errors that err matches, in the order they were registered.
+func Sentinels(err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	sentinelsMu.RLock()
+	defer sentinelsMu.RUnlock()
+
+	var names []string
+
+	for _, s := range sentinels {
+		if errors.Is(err, s.err) {
+			names = append(names, s.name)
+		}
+	}
+
+	return names
+}
+
+// ErrorChain returns err followed by the errors it wraps, depth first for errors wrapping more than one.
+func ErrorChain(err error) []ChainLink {
+	links := make([]ChainLink, 0)
+	walkChain(err, &links)
+
+	return links
+}
+
+func walkChain(err error, links *[]ChainLink) {
+	if err == nil || len(*links) >= maxChainDepth {
+		return
+	}
+
+	*links = append(*links, ChainLink{Type: fmt.Sprintf("%T", err), Message: err.Error()})
+
+	switch e := err.(type) { // nolint:errorlint // the wrapped errors of this error are needed, not of the chain.
+	case interface{ Unwrap() error }:
+		walkChain(e.Unwrap(), links)
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range e.Unwrap() {
+			walkChain(wrapped, links)
+		}
+	}
+}
+
+// errorFields adds the chain, the sentinels, the code and the category of err to fields, for the keys that are set.
+// The chain is only added when err wraps other errors. It is added as plain maps so that the redactor goes through it.
+func errorFields(err error, keys KeySettings, fields logrus.Fields) {
+	if keys.ErrorChainKey != "" {
+		if chain := ErrorChain(err); len(chain) > 1 {
+			links := make([]interface{}, len(chain))
+			for i, link := range chain {
+				links[i] = map[string]interface{}{"type": link.Type, "message": link.Message}
+			}
+
+			fields[string(keys.ErrorChainKey)] = links
+		}
+	}
+
+	if keys.ErrorSentinelsKey != "" {
+		if names := Sentinels(err); len(names) > 0 {
+			fields[string(keys.ErrorSentinelsKey)] = names
+		}
+	}
+
+	var coder ErrorCoder
+	if keys.ErrorCodeKey != "" && errors.As(err, &coder) {
+		fields[string(keys.ErrorCodeKey)] = coder.ErrorCode()
+	}
+
+	var categorizer ErrorCategorizer
+	if keys.ErrorCategoryKey != "" && errors.As(err, &categorizer) {
+		fields[string(keys.ErrorCategoryKey)] = categorizer.ErrorCategory()
+	}
+}
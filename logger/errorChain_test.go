@@ -0,0 +1,137 @@
+// nolint:testpackage // expects codedError under the name of the logger package in the chain.
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/mikarios/golib/handler"
+)
+
+var errTestSentinel = errors.New("sentinel")
+
+type codedError struct{}
+
+func (codedError) Error() string         { return "coded" }
+func (codedError) ErrorCode() string     { return "E42" }
+func (codedError) ErrorCategory() string { return "validation" }
+
+func TestErrorChain(t *testing.T) {
+	t.Parallel()
+
+	wrapped := fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", errTestSentinel))
+	joined := errors.Join(errTestSentinel, codedError{})
+
+	tests := []struct {
+		name string
+		err  error
+		want []ChainLink
+	}{
+		{name: "nil", err: nil, want: []ChainLink{}},
+		{name: "single", err: errTestSentinel, want: []ChainLink{{Type: "*errors.errorString", Message: "sentinel"}}},
+		{
+			name: "wrapped",
+			err:  wrapped,
+			want: []ChainLink{
+				{Type: "*fmt.wrapError", Message: "outer: inner: sentinel"},
+				{Type: "*fmt.wrapError", Message: "inner: sentinel"},
+				{Type: "*errors.errorString", Message: "sentinel"},
+			},
+		},
+		{
+			name: "joined",
+			err:  joined,
+			want: []ChainLink{
+				{Type: "*errors.joinError", Message: "sentinel\ncoded"},
+				{Type: "*errors.errorString", Message: "sentinel"},
+				{Type: "logger.codedError", Message: "coded"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := ErrorChain(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ErrorChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorFields(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	RegisterSentinel("logger.errTestSentinel", errTestSentinel)
+
+	l := New()
+	l.SetOutput(&buf)
+
+	ctx := context.TODO()
+
+	l.Error(ctx, fmt.Errorf("could not save: %w", errors.Join(errTestSentinel, codedError{})))
+
+	entry := decodeLine(t, &buf)
+
+	if got := entry["error_sentinels"]; !reflect.DeepEqual(got, []interface{}{"logger.errTestSentinel"}) {
+		t.Error("expected the registered sentinel, got:", got)
+	}
+
+	if entry["error_code"] != "E42" || entry["error_category"] != "validation" {
+		t.Error("expected the code and category of the wrapped error, got:", entry)
+	}
+
+	if chain, _ := entry["error_chain"].([]interface{}); len(chain) != 4 {
+		t.Error("expected the whole chain, got:", entry["error_chain"])
+	}
+
+	buf.Reset()
+	l.Error(ctx, fmt.Errorf("%w: bad input", ErrInvalidFormatter))
+
+	entry = decodeLine(t, &buf)
+	if got := entry["error_sentinels"]; !reflect.DeepEqual(got, []interface{}{"logger.ErrInvalidFormatter"}) {
+		t.Error("expected the sentinel of the logger, got:", got)
+	}
+
+	if _, ok := entry["error_code"]; ok {
+		t.Error("expected no code for errors without one, got:", entry)
+	}
+
+	buf.Reset()
+	l.Error(ctx, errText)
+
+	entry = decodeLine(t, &buf)
+	if _, ok := entry["error_chain"]; ok {
+		t.Error("expected no chain for errors that wrap nothing, got:", entry)
+	}
+}
+
+func TestRegisterSentinels(t *testing.T) {
+	t.Parallel()
+
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+
+	RegisterSentinels(map[string]error{"logger.errSecond": errSecond, "logger.errFirst": errFirst})
+
+	got := Sentinels(errors.Join(errSecond, errFirst))
+	if want := []string{"logger.errFirst", "logger.errSecond"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Sentinels() = %v, want %v", got, want)
+	}
+}
+
+func TestHandlerSentinels(t *testing.T) {
+	t.Parallel()
+
+	got := Sentinels(fmt.Errorf("id: %w", handler.ErrConversion))
+	if want := []string{"handler.ErrConversion"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Sentinels() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Field is a named value that is logged as a top level key of the entry instead of being added to the message list.
+// Fields can be passed among the messages of any log call or bound to a logger with With.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Fields is a set of named values that are logged as top level keys of the entry. It can be passed among the
+// messages of any log call.
+type Fields map[string]interface{}
+
+// Any creates a Field with any value. The value is marshalled as it is by the formatter.
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// String creates a Field with a string value.
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int creates a Field with an int value.
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int64 creates a Field with an int64 value.
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Float64 creates a Field with a float64 value.
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool creates a Field with a bool value.
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration creates a Field with the duration in its human-readable form, e.g. "1.5s".
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value.String()}
+}
+
+// Time creates a Field with a time value formatted as RFC3339 with nanoseconds.
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, Value: value.Format(time.RFC3339Nano)}
+}
+
+// With returns a logger derived from the default logger that adds fields to every entry.
+func With(fields ...Field) *Logger {
+	return Default().With(fields...)
+}
+
+type (
+	contextFieldsKey struct{}
+	fieldScopeKey    struct{}
+)
+
+// fieldScope collects the fields added with WithFields under the context that created it.
+type fieldScope struct {
+	mu     sync.RWMutex
+	fields Fields
+	parent *fieldScope
+}
+
+func (s *fieldScope) add(fields []Field) {
+	for ; s != nil; s = s.parent {
+		s.mu.Lock()
+
+		for _, f := range fields {
+			s.fields[f.Key] = f.Value
+		}
+
+		s.mu.Unlock()
+	}
+}
+
+// WithFields returns a copy of ctx that carries fields. Every entry logged with the returned context, or with
+// contexts derived from it, gets them as top level keys. Fields already in ctx are kept unless a new field has the
+// same key, in which case the new one wins. Fields are also added to the scope of ctx, if it has one, see
+// WithFieldScope.
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	stored, _ := ctx.Value(contextFieldsKey{}).(Fields)
+	merged := make(Fields, len(stored)+len(fields))
+
+	for k, v := range stored {
+		merged[k] = v
+	}
+
+	for _, f := range fields {
+		merged[f.Key] = f.Value
+	}
+
+	if scope, _ := ctx.Value(fieldScopeKey{}).(*fieldScope); scope != nil {
+		scope.add(fields)
+	}
+
+	return context.WithValue(ctx, contextFieldsKey{}, merged)
+}
+
+// WithFieldScope returns a copy of ctx with a scope that collects the fields added with WithFields to contexts derived
+// from it. Entries logged with the returned context get the collected fields too, so that a middleware that creates
+// the scope logs the fields its handler added, e.g. a user id known only after authentication. Fields of the context
+// itself win over collected ones with the same key. The scope is safe for concurrent use.
+func WithFieldScope(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	parent, _ := ctx.Value(fieldScopeKey{}).(*fieldScope)
+
+	return context.WithValue(ctx, fieldScopeKey{}, &fieldScope{fields: Fields{}, parent: parent})
+}
+
+// ContextFields returns a copy of the fields stored in ctx with WithFields, and of the ones collected by its scope.
+func ContextFields(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+
+	stored, _ := ctx.Value(contextFieldsKey{}).(Fields)
+	scope, _ := ctx.Value(fieldScopeKey{}).(*fieldScope)
+
+	if scope != nil {
+		scope.mu.RLock()
+		defer scope.mu.RUnlock()
+	}
+
+	if stored == nil && (scope == nil || len(scope.fields) == 0) {
+		return nil
+	}
+
+	fields := Fields{}
+
+	if scope != nil {
+		for k, v := range scope.fields {
+			fields[k] = v
+		}
+	}
+
+	for k, v := range stored {
+		fields[k] = v
+	}
+
+	return fields
+}
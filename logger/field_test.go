@@ -0,0 +1,178 @@
+// nolint:testpackage // decodes the lines with decodeLine of slog_test.go.
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFields(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+
+	ctx := context.WithValue(context.TODO(), Settings.TransactionKey, "abc")
+
+	l.Info(
+		ctx,
+		"user logged in",
+		String("user", "u-1"),
+		Int("attempts", 3),
+		Duration("took", 1500*time.Millisecond),
+		Fields{"tenant": "t-1", "txID": "overridden"},
+	)
+
+	line := decodeLine(t, &buf)
+
+	if line["user"] != "u-1" ||
+		line["attempts"] != float64(3) ||
+		line["took"] != "1.5s" ||
+		line["tenant"] != "t-1" {
+		t.Error("expected fields as top level keys, got:", line)
+	}
+
+	if line["txID"] != "abc" {
+		t.Error("expected context transaction to win over fields, got:", line["txID"])
+	}
+
+	messages, ok := line["message"].([]interface{})
+	if !ok || len(messages) != 1 || messages[0] != "user logged in" {
+		t.Error("expected only plain messages in message key, got:", line["message"])
+	}
+
+	buf.Reset()
+	l.Info(ctx, Bool("only", true))
+
+	line = decodeLine(t, &buf)
+	if _, ok := line["message"]; ok || line["only"] != true {
+		t.Error("expected no message key when only fields are logged, got:", line)
+	}
+}
+
+func TestWith(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+
+	child := l.With(String("component", "billing"), String("region", "eu"))
+	grandChild := child.With(String("region", "us"))
+
+	grandChild.Info(context.TODO(), "test", String("user", "u-1"))
+
+	line := decodeLine(t, &buf)
+
+	if line["component"] != "billing" || line["region"] != "us" || line["user"] != "u-1" {
+		t.Error("expected bound fields with the latest winning, got:", line)
+	}
+
+	buf.Reset()
+	l.Info(context.TODO(), "test")
+
+	if line = decodeLine(t, &buf); line["component"] != nil {
+		t.Error("expected parent logger to be unaffected by With, got:", line)
+	}
+
+	if err := l.SetLogLevel("warn"); err != nil {
+		t.Error("could not set log level", err)
+	}
+
+	buf.Reset()
+	child.Info(context.TODO(), "test")
+
+	if buf.Len() > 0 {
+		t.Error("expected child logger to share the level of its parent, got:", buf.String())
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+
+	parent := WithFields(context.TODO(), String("tenant", "t-1"), String("user", "u-1"))
+	child := WithFields(parent, String("user", "u-2"))
+
+	l.With(String("component", "billing")).Info(child, "test")
+
+	line := decodeLine(t, &buf)
+
+	if line["tenant"] != "t-1" || line["user"] != "u-2" || line["component"] != "billing" {
+		t.Error("expected context fields with the child winning, got:", line)
+	}
+
+	buf.Reset()
+	l.Info(parent, "test", String("tenant", "t-2"))
+
+	if line = decodeLine(t, &buf); line["user"] != "u-1" || line["tenant"] != "t-2" {
+		t.Error("expected parent context to be unaffected and call fields to win, got:", line)
+	}
+
+	buf.Reset()
+	l.SetSlogHandler(NewSlogJSONHandler(&buf, nil))
+	l.Info(child, "test")
+
+	if line = decodeLine(t, &buf); line["tenant"] != "t-1" || line["user"] != "u-2" {
+		t.Error("expected context fields through slog, got:", line)
+	}
+
+	if fields := ContextFields(context.TODO()); fields != nil {
+		t.Error("expected no fields in an empty context, got:", fields)
+	}
+}
+
+func TestWithFieldScope(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+
+	outer := WithFields(context.TODO(), String("tenant", "t-1"))
+	scoped := WithFieldScope(outer)
+
+	// Fields added further down the call chain, as a handler would.
+	inner := WithFields(scoped, String("user", "u-1"), String("tenant", "t-2"))
+	_ = WithFields(inner, Int("attempt", 2))
+
+	l.Info(scoped, "finished")
+
+	line := decodeLine(t, &buf)
+	if line["user"] != "u-1" || line["attempt"] != float64(2) || line["tenant"] != "t-1" {
+		t.Error("expected the collected fields with the fields of the context winning, got:", line)
+	}
+
+	buf.Reset()
+	l.Info(outer, "outside")
+
+	if line = decodeLine(t, &buf); line["user"] != nil {
+		t.Error("expected the context above the scope to be unaffected, got:", line)
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			_ = WithFields(scoped, Int("i", i))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		_ = ContextFields(scoped)
+	}
+
+	<-done
+}
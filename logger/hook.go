@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogLevel is the type of the values of LogLevels, so that other packages can refer to it.
+type LogLevel = logLevelType
+
+// Entry is a log entry as handed to hooks. Fields holds every field of the entry after redaction, including the ones
+// named by KeySettings.
+type Entry struct {
+	Time  time.Time `json:"time"`
+	Level LogLevel  `json:"level"`
+	// Message is the text of the messages of the log call, separated by spaces.
+	Message string `json:"message"`
+	// Error is the error of the log call as it was passed, the redacted text is in Fields under KeySettings.ErrorKey.
+	Error      error        `json:"-"`
+	TxID       string       `json:"txID,omitempty"`
+	Identifier string       `json:"identifier,omitempty"`
+	Trace      []StackTrace `json:"trace,omitempty"`
+	Fields     Fields       `json:"fields"`
+}
+
+// Hook is called with every entry the logger writes at one of its Levels, before writing it. Fire runs on the
+// goroutine of the log call, so it should not block, and it must not modify the fields of the entry, which are shared
+// with the other hooks.
+type Hook interface {
+	Levels() []LogLevel
+	Fire(ctx context.Context, entry Entry)
+}
+
+type hook struct {
+	hook   Hook
+	levels map[logrus.Level]bool
+}
+
+// All returns every level, from PANIC to TRACE.
+func (levels logLevels) All() []LogLevel {
+	return []LogLevel{levels.PANIC, levels.FATAL, levels.ERROR, levels.WARNING, levels.INFO, levels.DEBUG, levels.TRACE}
+}
+
+// AddHook adds a hook to the default logger. See Logger.AddHook.
+func AddHook(h Hook) {
+	Default().AddHook(h)
+}
+
+// RemoveHooks removes every hook of the default logger.
+func RemoveHooks() {
+	Default().RemoveHooks()
+}
+
+// AddHook makes the logger call h for the entries it writes at the levels of h. Entries only written to sinks do not
+// fire hooks. Levels that cannot be parsed are ignored.
+func (l *Logger) AddHook(h Hook) {
+	levels := make(map[logrus.Level]bool)
+
+	for _, levelType := range h.Levels() {
+		if level, err := logrus.ParseLevel(string(levelType)); err == nil {
+			levels[level] = true
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.hooks = append(l.hooks, &hook{hook: h, levels: levels})
+}
+
+// RemoveHooks removes every hook of the logger.
+func (l *Logger) RemoveHooks() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.hooks = nil
+}
+
+func (l *Logger) currentHooks() []*hook {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.hooks
+}
+
+func (l *Logger) fireHooks(ctx context.Context, level logrus.Level, err error, fields logrus.Fields) {
+	hooks := l.currentHooks()
+	if len(hooks) == 0 {
+		return
+	}
+
+	keys := l.KeySettings()
+
+	entry := Entry{
+		Time:    time.Now(),
+		Level:   toLevelType(level),
+		Message: joinMessages(fields[string(keys.MessageKey)]),
+		Error:   err,
+		Fields:  make(Fields, len(fields)),
+	}
+
+	if txID := fields[string(keys.TransactionKey)]; txID != nil {
+		entry.TxID = fmt.Sprint(txID)
+	}
+
+	if identifier := fields[string(keys.IdentifierKey)]; identifier != nil {
+		entry.Identifier = fmt.Sprint(identifier)
+	}
+
+	entry.Trace, _ = fields[string(keys.TraceKey)].([]StackTrace)
+
+	for k, v := range fields {
+		entry.Fields[k] = v
+	}
+
+	for _, h := range hooks {
+		if h.levels[level] {
+			h.hook.Fire(ctx, entry)
+		}
+	}
+}
+
+func joinMessages(messages interface{}) string {
+	list, ok := messages.([]interface{})
+	if !ok {
+		return ""
+	}
+
+	texts := make([]string, len(list))
+	for i, m := range list {
+		texts[i] = fmt.Sprint(m)
+	}
+
+	return strings.Join(texts, " ")
+}
+
+func toLevelType(level logrus.Level) LogLevel {
+	switch level {
+	case logrus.PanicLevel:
+		return LogLevels.PANIC
+	case logrus.FatalLevel:
+		return LogLevels.FATAL
+	case logrus.ErrorLevel:
+		return LogLevels.ERROR
+	case logrus.WarnLevel:
+		return LogLevels.WARNING
+	case logrus.InfoLevel:
+		return LogLevels.INFO
+	case logrus.DebugLevel:
+		return LogLevels.DEBUG
+	default:
+		return LogLevels.TRACE
+	}
+}
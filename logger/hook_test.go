@@ -0,0 +1,63 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/mikarios/golib/logger"
+)
+
+type errorHook struct {
+	entries []logger.Entry
+}
+
+func (h *errorHook) Levels() []logger.LogLevel {
+	return []logger.LogLevel{logger.LogLevels.ERROR, logger.LogLevels.INFO}
+}
+
+func (h *errorHook) Fire(_ context.Context, entry logger.Entry) {
+	h.entries = append(h.entries, entry)
+}
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+
+	var buf, sinkBuf bytes.Buffer
+
+	l := logger.New()
+	l.SetOutput(&buf)
+
+	if err := l.SetLogLevel("error"); err != nil {
+		t.Fatal("could not set log level", err)
+	}
+
+	if err := l.AddSink(logger.Sink{Output: &sinkBuf, Level: "debug", Formatter: "json"}); err != nil {
+		t.Fatal("could not add sink", err)
+	}
+
+	h := &errorHook{}
+	l.AddHook(h)
+
+	ctx := context.WithValue(context.TODO(), logger.Settings.TransactionKey, "abc")
+
+	l.Info(ctx, "only on the sink")
+	l.Warning(ctx, "not a level of the hook")
+	l.Error(ctx, errFailed, "failed", "twice")
+
+	if len(h.entries) != 1 {
+		t.Fatal("expected only the error to fire the hook, got:", h.entries)
+	}
+
+	if e := h.entries[0]; e.Level != logger.LogLevels.ERROR || e.Message != "failed twice" || e.Error != errFailed ||
+		e.TxID != "abc" || e.Fields["error"] != errFailed.Error() {
+		t.Error("unexpected entry:", e)
+	}
+
+	l.RemoveHooks()
+	l.Error(ctx, errFailed)
+
+	if len(h.entries) != 1 {
+		t.Error("expected removed hooks not to fire, got:", h.entries)
+	}
+}
@@ -0,0 +1,409 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/mikarios/golib/redact"
+)
+
+// fatalFlushTimeout bounds how long Fatal waits for the outputs and hooks to flush before exiting.
+const fatalFlushTimeout = 10 * time.Second
+
+// Logger is a self-contained logger with its own output, formatter, level, trace option and key settings. The zero
+// value is not usable, use New instead.
+type Logger struct {
+	*core
+	fields []Field
+}
+
+// core holds the configuration that a Logger shares with the loggers derived from it with With.
+type core struct {
+	log        *logrus.Logger
+	out        io.Writer
+	settings   *KeySettings
+	slog       slog.Handler
+	sampler    *sampler
+	redactor   *redact.Redactor
+	sinks      []*sink
+	hooks      []*hook
+	levels     levelOverrides
+	level      logrus.Level
+	mu         sync.RWMutex
+	showTraces bool
+	spanEvents bool
+}
+
+// New creates a Logger that writes json to os.Stderr at debug level, which are the defaults of the package.
+func New() *Logger {
+	return &Logger{
+		core: &core{
+			out:   os.Stderr,
+			level: logrus.DebugLevel,
+			log: &logrus.Logger{
+				Out:          os.Stderr,
+				Formatter:    &logrus.JSONFormatter{},
+				Hooks:        make(logrus.LevelHooks),
+				Level:        logrus.DebugLevel,
+				ExitFunc:     os.Exit,
+				ReportCaller: false,
+			},
+		},
+	}
+}
+
+// With returns a logger that adds fields to every entry. It shares the output, level and every other setting with l,
+// so changing them on either of the two affects both.
+func (l *Logger) With(fields ...Field) *Logger {
+	merged := make([]Field, 0, len(l.fields)+len(fields))
+	merged = append(merged, l.fields...)
+	merged = append(merged, fields...)
+
+	return &Logger{core: l.core, fields: merged}
+}
+
+// NewLogger creates a Printf logger that writes through l at the given level.
+func (l *Logger) NewLogger(identifier string, levelType logLevelType) *exportedLogger {
+	return &exportedLogger{
+		logger:     l,
+		identifier: identifier,
+		level:      levelType,
+	}
+}
+
+// SetOutput changes the output of the logger. It has no effect while the logger emits through slog.
+func (l *Logger) SetOutput(out io.Writer) {
+	l.mu.Lock()
+	l.out = out
+	l.mu.Unlock()
+
+	l.log.SetOutput(out)
+}
+
+// SetFormatter changes the formatter for the logs. It has no effect while the logger emits through slog.
+// Valid values are: "json", "text".
+func (l *Logger) SetFormatter(formatter string) error {
+	f, err := parseFormatter(formatter)
+	if err != nil {
+		return err
+	}
+
+	l.log.SetFormatter(f)
+
+	return nil
+}
+
+func parseFormatter(formatter string) (logrus.Formatter, error) {
+	switch strings.ToLower(formatter) {
+	case "json":
+		return &logrus.JSONFormatter{}, nil
+	case "text":
+		return &logrus.TextFormatter{
+			FullTimestamp: true,
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w : %v", ErrInvalidFormatter, formatter)
+	}
+}
+
+// SetLogLevel changes the level of the logger. Acceptable strings are based on logrus. It cancels the reversion of a
+// level set with SetTemporaryLogLevel.
+func (l *Logger) SetLogLevel(level string) error {
+	logLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("could not parse error level %v : %w", level, err)
+	}
+
+	l.setBaseLevel(logLevel, 0)
+
+	return nil
+}
+
+// GetLogLevel returns the current level of the logger, without the identifier overrides.
+func (l *Logger) GetLogLevel() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.level.String()
+}
+
+// SetLogTrace changes the showTrace option.
+func (l *Logger) SetLogTrace(show bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.showTraces = show
+}
+
+// SetKeySettings gives the logger its own keys instead of following the package level Settings.
+func (l *Logger) SetKeySettings(settings KeySettings) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.settings = &settings
+}
+
+// KeySettings returns the keys the logger currently uses.
+func (l *Logger) KeySettings() KeySettings {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.keySettings()
+}
+
+// keySettings must be called with the lock held.
+func (l *Logger) keySettings() KeySettings {
+	if l.settings != nil {
+		return *l.settings
+	}
+
+	return Settings
+}
+
+// SetSlogHandler makes the logger emit through h instead of logrus. The level set by SetLogLevel still applies.
+// Passing nil switches back to logrus.
+func (l *Logger) SetSlogHandler(h slog.Handler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.slog = h
+}
+
+// SetRedactor makes the logger redact every entry with r before emitting it: fields matching its json field rules are
+// redacted, as well as pattern matches in the message, the error and string values. Passing nil stops redacting.
+func (l *Logger) SetRedactor(r *redact.Redactor) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.redactor = r
+}
+
+// Redactor returns the redactor of the logger, nil if it does not redact.
+func (l *Logger) Redactor() *redact.Redactor {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.redactor
+}
+
+func (l *Logger) slogHandler() slog.Handler {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.slog
+}
+
+func (l *Logger) traces() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.showTraces
+}
+
+// Panic gets the transaction from context.
+func (l *Logger) Panic(ctx context.Context, err error, messages ...interface{}) {
+	if err != nil {
+		l.write(ctx, logrus.PanicLevel, err, l.traces(), messages...)
+	}
+}
+
+// Fatal uses ctx to extract information about the transaction in order to log it.
+// Messages are logged in KeySettings.MessageKey (default is "message"). The outputs and hooks are flushed, for at most
+// 10 seconds, before the program exits.
+func (l *Logger) Fatal(ctx context.Context, err error, messages ...interface{}) {
+	l.write(ctx, logrus.FatalLevel, err, l.traces(), messages...)
+}
+
+// Error uses ctx to extract information about the transaction in order to log it.
+// Messages are logged in KeySettings.MessageKey (default is "message").
+func (l *Logger) Error(ctx context.Context, err error, messages ...interface{}) {
+	l.write(ctx, logrus.ErrorLevel, err, l.traces(), messages...)
+}
+
+// Warning uses ctx to extract information about the transaction in order to log it.
+// Messages are logged in KeySettings.MessageKey (default is "message").
+func (l *Logger) Warning(ctx context.Context, messages ...interface{}) {
+	l.write(ctx, logrus.WarnLevel, nil, l.traces(), messages...)
+}
+
+// Info uses ctx to extract information about the transaction in order to log it.
+// Messages are logged in KeySettings.MessageKey (default is "message").
+func (l *Logger) Info(ctx context.Context, messages ...interface{}) {
+	l.write(ctx, logrus.InfoLevel, nil, false, messages...)
+}
+
+// Debug uses ctx to extract information about the transaction in order to log it.
+// Messages are logged in KeySettings.MessageKey (default is "message").
+func (l *Logger) Debug(ctx context.Context, messages ...interface{}) {
+	l.write(ctx, logrus.DebugLevel, nil, false, messages...)
+}
+
+// Trace uses ctx to extract information about the transaction in order to log it.
+// Messages are logged in KeySettings.MessageKey (default is "message").
+func (l *Logger) Trace(ctx context.Context, messages ...interface{}) {
+	l.write(ctx, logrus.TraceLevel, nil, false, messages...)
+}
+
+// write drops the entry if its level is disabled or the sampler decides so, otherwise it emits it.
+func (l *Logger) write(
+	ctx context.Context,
+	level logrus.Level,
+	err error,
+	showStackTrace bool,
+	messages ...interface{},
+) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if !l.isLevelEnabled(ctx, level) {
+		return
+	}
+
+	if s := l.currentSampler(); s != nil && !s.allow(level, samplingMessage(err, messages)) {
+		return
+	}
+
+	l.emit(ctx, level, err, showStackTrace, messages...)
+}
+
+// emit builds the entry and sends it to the sinks and then to slog if a handler is set, otherwise to logrus. Entries
+// with an error use the error text as their message, the same way logrus does when logging an error.
+func (l *Logger) emit(
+	ctx context.Context,
+	level logrus.Level,
+	err error,
+	showStackTrace bool,
+	messages ...interface{},
+) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	fields := l.parseMessages(ctx, err, showStackTrace, messages...)
+
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+
+	if r := l.Redactor(); r != nil {
+		fields, _ = r.Value(map[string]interface{}(fields)).(map[string]interface{})
+		msg = r.String(msg)
+	}
+
+	l.writeSinks(ctx, level, msg, fields)
+	l.recordSpanEvent(ctx, level, err, msg, fields)
+
+	// The level of logrus follows the most verbose override, so the level of this entry is checked here.
+	primary := level <= l.effectiveLevel(ctx)
+
+	if primary {
+		l.fireHooks(ctx, level, err, fields)
+	}
+
+	if h := l.slogHandler(); h != nil {
+		if primary {
+			l.writeSlog(ctx, h, level, msg, fields)
+		}
+
+		if level == logrus.FatalLevel {
+			l.exit()
+		}
+
+		return
+	}
+
+	// logrus panics on its own after writing a panic entry.
+	if primary {
+		l.log.WithContext(ctx).WithFields(fields).Log(level, msg)
+	}
+
+	if level == logrus.FatalLevel {
+		l.exit()
+	}
+}
+
+// exit flushes the outputs and hooks, so that buffered entries and the fatal one itself are not lost, waiting at most
+// fatalFlushTimeout, and exits with the ExitFunc of logrus.
+func (l *Logger) exit() {
+	ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+	_ = l.Flush(ctx)
+
+	cancel()
+	l.log.Exit(1)
+}
+
+// parseMessages turns the arguments of a log call into the fields of the entry. Field and Fields arguments become top
+// level keys, everything else is logged in KeySettings.MessageKey. Fields of the call win over fields bound with With,
+// which win over fields stored in the context with WithFields. The keys read from the context, the ids of the
+// OpenTelemetry span and the message, error and trace keys win over all of them. An error is also described by its
+// unwrap chain, the sentinels it matches and its code and category.
+func (l *Logger) parseMessages(
+	ctx context.Context,
+	err error,
+	showStackTrace bool,
+	messages ...interface{},
+) logrus.Fields {
+	keys := l.KeySettings()
+	fields := logrus.Fields{}
+
+	for k, v := range ContextFields(ctx) {
+		fields[k] = v
+	}
+
+	for _, f := range l.fields {
+		fields[f.Key] = f.Value
+	}
+
+	rest := make([]interface{}, 0, len(messages))
+	traceErr := err
+
+	for _, m := range messages {
+		switch v := m.(type) {
+		case Field:
+			fields[v.Key] = v.Value
+		case Fields:
+			for k, value := range v {
+				fields[k] = value
+			}
+		case error:
+			if traceErr == nil {
+				traceErr = v
+			}
+
+			rest = append(rest, v.Error())
+		default:
+			rest = append(rest, m)
+		}
+	}
+
+	fields[string(keys.TransactionKey)] = ctx.Value(keys.TransactionKey)
+	fields[string(keys.LogInfoKey)] = ctx.Value(keys.LogInfoKey)
+	fields[string(keys.IdentifierKey)] = ctx.Value(keys.IdentifierKey)
+
+	spanFields(ctx, keys, fields)
+
+	if len(rest) > 0 {
+		fields[string(keys.MessageKey)] = rest
+	}
+
+	if showStackTrace {
+		fields[string(keys.TraceKey)] = findTrace(traceErr)
+	}
+
+	if err != nil {
+		errorFields(err, keys, fields)
+		fields[string(keys.ErrorKey)] = err.Error()
+	}
+
+	return fields
+}
@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelOverride is the level used for the entries of an identifier instead of the level of the logger.
+type LevelOverride struct {
+	Level string `json:"level"`
+	// ExpiresAt is when the override is removed, nil if it never is.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// LevelState is the level of a logger together with its overrides per identifier.
+type LevelState struct {
+	Level string `json:"level"`
+	// RevertsAt is when a level set with SetTemporaryLogLevel reverts, nil if there is none.
+	RevertsAt *time.Time               `json:"revertsAt,omitempty"`
+	Overrides map[string]LevelOverride `json:"overrides"`
+}
+
+type levelOverride struct {
+	level     logrus.Level
+	expiresAt time.Time
+	timer     *time.Timer
+}
+
+type levelOverrides struct {
+	byIdentifier map[string]*levelOverride
+	revert       *time.Timer
+	revertsAt    time.Time
+	// base is the level restored by revert.
+	base logrus.Level
+}
+
+// SetTemporaryLogLevel changes the level of the logger for ttl, after which the current level is restored. A ttl of 0
+// works like SetLogLevel. Calling SetLogLevel before that cancels the reversion. Calling SetTemporaryLogLevel again
+// replaces the temporary level and its ttl, and the level restored stays the one before the first temporary level.
+func (l *Logger) SetTemporaryLogLevel(level string, ttl time.Duration) error {
+	logLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("could not parse error level %v : %w", level, err)
+	}
+
+	l.setBaseLevel(logLevel, ttl)
+
+	return nil
+}
+
+// SetIdentifierLogLevel makes the entries logged with the given identifier in their context, e.g. by the loggers of
+// NewLogger, use level instead of the level of the logger. A positive ttl removes the override after that long.
+func (l *Logger) SetIdentifierLogLevel(identifier, level string, ttl time.Duration) error {
+	logLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("could not parse error level %v : %w", level, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.levels.byIdentifier == nil {
+		l.levels.byIdentifier = make(map[string]*levelOverride)
+	}
+
+	l.removeOverride(identifier)
+
+	override := &levelOverride{level: logLevel}
+
+	if ttl > 0 {
+		override.expiresAt = time.Now().Add(ttl)
+		override.timer = time.AfterFunc(ttl, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			if l.levels.byIdentifier[identifier] == override {
+				l.removeOverride(identifier)
+				l.syncLogrusLevel()
+			}
+		})
+	}
+
+	l.levels.byIdentifier[identifier] = override
+	l.syncLogrusLevel()
+
+	return nil
+}
+
+// RemoveIdentifierLogLevel removes the override of the identifier, if any.
+func (l *Logger) RemoveIdentifierLogLevel(identifier string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.removeOverride(identifier)
+	l.syncLogrusLevel()
+}
+
+// LevelState returns the level of the logger and its overrides.
+func (l *Logger) LevelState() LevelState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	state := LevelState{Level: l.level.String(), Overrides: make(map[string]LevelOverride)}
+
+	if l.levels.revert != nil {
+		revertsAt := l.levels.revertsAt
+		state.RevertsAt = &revertsAt
+	}
+
+	for identifier, override := range l.levels.byIdentifier {
+		o := LevelOverride{Level: override.level.String()}
+
+		if override.timer != nil {
+			expiresAt := override.expiresAt
+			o.ExpiresAt = &expiresAt
+		}
+
+		state.Overrides[identifier] = o
+	}
+
+	return state
+}
+
+// setBaseLevel changes the level of the logger. A positive ttl restores the level the logger had before any pending
+// temporary level after that long.
+func (l *Logger) setBaseLevel(level logrus.Level, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	base := l.level
+
+	if l.levels.revert != nil {
+		l.levels.revert.Stop()
+		l.levels.revert = nil
+		base = l.levels.base
+	}
+
+	if ttl > 0 {
+		var revert *time.Timer
+
+		revert = time.AfterFunc(ttl, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			if l.levels.revert == revert {
+				l.levels.revert = nil
+				l.level = l.levels.base
+				l.syncLogrusLevel()
+			}
+		})
+
+		l.levels.base = base
+
+		l.levels.revert = revert
+		l.levels.revertsAt = time.Now().Add(ttl)
+	}
+
+	l.level = level
+	l.syncLogrusLevel()
+}
+
+// effectiveLevel returns the level that applies to the entries logged with ctx.
+func (l *Logger) effectiveLevel(ctx context.Context) logrus.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if len(l.levels.byIdentifier) > 0 && ctx != nil {
+		if identifier, ok := ctx.Value(l.keySettings().IdentifierKey).(string); ok {
+			if override, found := l.levels.byIdentifier[identifier]; found {
+				return override.level
+			}
+		}
+	}
+
+	return l.level
+}
+
+// removeOverride must be called with the lock held.
+func (l *Logger) removeOverride(identifier string) {
+	if override, ok := l.levels.byIdentifier[identifier]; ok {
+		if override.timer != nil {
+			override.timer.Stop()
+		}
+
+		delete(l.levels.byIdentifier, identifier)
+	}
+}
+
+// syncLogrusLevel sets the level of logrus to the most verbose of the level of the logger and its overrides, so that
+// logrus never drops an entry the logger decided to write. It must be called with the lock held.
+func (l *Logger) syncLogrusLevel() {
+	level := l.level
+
+	for _, override := range l.levels.byIdentifier {
+		if override.level > level {
+			level = override.level
+		}
+	}
+
+	l.log.SetLevel(level)
+}
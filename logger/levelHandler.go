@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrInvalidLevelRequest is returned by the level handler for requests it cannot apply.
+var ErrInvalidLevelRequest = errors.New("invalid level request")
+
+type levelRequest struct {
+	Level      string `json:"level"`
+	Identifier string `json:"identifier"`
+	TTL        string `json:"ttl"`
+}
+
+type levelError struct {
+	Error string `json:"error"`
+}
+
+// LevelHandler returns an http.Handler that reads and changes the level of the default logger at runtime, whichever
+// logger is the default at the time of the request. See Logger.LevelHandler.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Default().LevelHandler().ServeHTTP(w, r)
+	})
+}
+
+// LevelHandler returns an http.Handler that reads and changes the level of the logger at runtime. It can be mounted
+// on any path, e.g. router.Handle("/log/level", l.LevelHandler()) with gorilla/mux. Every response is the LevelState
+// of the logger as json.
+//
+//	GET                                                        returns the current state.
+//	PUT {"level": "debug", "ttl": "10m"}                       changes the level, reverting after ttl if given.
+//	PUT {"level": "trace", "identifier": "amqp", "ttl": "5m"}  overrides the level of an identifier.
+//	DELETE ?identifier=amqp                                    removes the override of an identifier.
+func (l *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			err = l.applyLevelRequest(r)
+		case http.MethodDelete:
+			identifier := r.URL.Query().Get("identifier")
+			if identifier == "" {
+				err = fmt.Errorf("%w: identifier is required", ErrInvalidLevelRequest)
+			} else {
+				l.RemoveIdentifierLogLevel(identifier)
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			writeJSON(w, http.StatusMethodNotAllowed, levelError{Error: "method not allowed"})
+
+			return
+		}
+
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, levelError{Error: err.Error()})
+
+			return
+		}
+
+		writeJSON(w, http.StatusOK, l.LevelState())
+	})
+}
+
+func (l *Logger) applyLevelRequest(r *http.Request) error {
+	var req levelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidLevelRequest, err)
+	}
+
+	var ttl time.Duration
+
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+			return fmt.Errorf("%w: invalid ttl %v", ErrInvalidLevelRequest, req.TTL)
+		}
+	}
+
+	if req.Identifier != "" {
+		return l.SetIdentifierLogLevel(req.Identifier, req.Level, ttl)
+	}
+
+	return l.SetTemporaryLogLevel(req.Level, ttl)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,215 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikarios/golib/logger"
+)
+
+func levelRequestTo(t *testing.T, h http.Handler, method, target, body string) (int, logger.LevelState) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+	var state logger.LevelState
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+			t.Fatal("could not decode state", rec.Body.String(), err)
+		}
+	}
+
+	return rec.Code, state
+}
+
+// eventually fails the test if cond does not become true within a few seconds, which leaves timers plenty of room
+// even on slow machines.
+func eventually(t *testing.T, cond func() bool, msg ...interface{}) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if cond() {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatal(msg...)
+}
+
+func TestIdentifierLogLevel(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := logger.New()
+	l.SetOutput(&buf)
+
+	if err := l.SetLogLevel("info"); err != nil {
+		t.Fatal("could not set log level", err)
+	}
+
+	if err := l.SetIdentifierLogLevel("amqp", "debug", 0); err != nil {
+		t.Fatal("could not set identifier log level", err)
+	}
+
+	if err := l.SetIdentifierLogLevel("noisy", "error", 0); err != nil {
+		t.Fatal("could not set identifier log level", err)
+	}
+
+	l.NewLogger("amqp", logger.LogLevels.DEBUG).Printf("amqp debug")
+	l.NewLogger("http", logger.LogLevels.DEBUG).Printf("http debug")
+	l.NewLogger("noisy", logger.LogLevels.WARNING).Printf("noisy warning")
+	l.Debug(context.TODO(), "no identifier debug")
+
+	if out := buf.String(); !strings.Contains(out, "amqp debug") ||
+		strings.Contains(out, "http debug") ||
+		strings.Contains(out, "noisy warning") ||
+		strings.Contains(out, "no identifier debug") {
+		t.Error("expected only the overridden identifier at debug, got:", out)
+	}
+
+	if l.GetLogLevel() != "info" {
+		t.Error("expected the level of the logger to stay info, got", l.GetLogLevel())
+	}
+
+	if err := l.SetIdentifierLogLevel("amqp", "debug", 10*time.Millisecond); err != nil {
+		t.Fatal("could not set identifier log level", err)
+	}
+
+	eventually(t, func() bool {
+		_, ok := l.LevelState().Overrides["amqp"]
+
+		return !ok
+	}, "expected override to be removed after its ttl")
+
+	buf.Reset()
+	l.NewLogger("amqp", logger.LogLevels.DEBUG).Printf("amqp debug")
+
+	if buf.Len() > 0 {
+		t.Error("expected no debug after the override expired, got:", buf.String())
+	}
+}
+
+func TestTemporaryLogLevel(t *testing.T) {
+	t.Parallel()
+
+	l := logger.New()
+
+	if err := l.SetLogLevel("warn"); err != nil {
+		t.Fatal("could not set log level", err)
+	}
+
+	if err := l.SetTemporaryLogLevel("trace", time.Hour); err != nil {
+		t.Fatal("could not set temporary log level", err)
+	}
+
+	if state := l.LevelState(); state.Level != "trace" || state.RevertsAt == nil {
+		t.Error("unexpected state", state)
+	}
+
+	if err := l.SetTemporaryLogLevel("trace", 10*time.Millisecond); err != nil {
+		t.Fatal("could not set temporary log level", err)
+	}
+
+	eventually(t, func() bool {
+		state := l.LevelState()
+
+		return state.Level == "warning" && state.RevertsAt == nil
+	}, "expected level to revert")
+}
+
+func TestNestedTemporaryLogLevel(t *testing.T) {
+	t.Parallel()
+
+	l := logger.New()
+
+	if err := l.SetLogLevel("info"); err != nil {
+		t.Fatal("could not set log level", err)
+	}
+
+	if err := l.SetTemporaryLogLevel("debug", time.Hour); err != nil {
+		t.Fatal("could not set temporary log level", err)
+	}
+
+	if err := l.SetTemporaryLogLevel("trace", 10*time.Millisecond); err != nil {
+		t.Fatal("could not set temporary log level", err)
+	}
+
+	// The second temporary level replaces the first one and reverts to the level before both.
+	eventually(t, func() bool {
+		state := l.LevelState()
+
+		return state.Level == "info" && state.RevertsAt == nil
+	}, "expected level to revert to info")
+
+	if err := l.SetTemporaryLogLevel("debug", time.Hour); err != nil {
+		t.Fatal("could not set temporary log level", err)
+	}
+
+	if err := l.SetLogLevel("error"); err != nil {
+		t.Fatal("could not set log level", err)
+	}
+
+	if state := l.LevelState(); state.Level != "error" || state.RevertsAt != nil {
+		t.Error("expected SetLogLevel to cancel the reversion, got", state)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	t.Parallel()
+
+	l := logger.New()
+	h := l.LevelHandler()
+
+	code, state := levelRequestTo(t, h, http.MethodGet, "/", "")
+	if code != http.StatusOK || state.Level != "debug" || len(state.Overrides) != 0 {
+		t.Error("unexpected GET response", code, state)
+	}
+
+	code, state = levelRequestTo(t, h, http.MethodPut, "/", `{"level":"error"}`)
+	if code != http.StatusOK || state.Level != "error" {
+		t.Error("unexpected PUT response", code, state)
+	}
+
+	code, state = levelRequestTo(t, h, http.MethodPut, "/", `{"level":"trace","identifier":"amqp","ttl":"1h"}`)
+	if code != http.StatusOK || state.Overrides["amqp"].Level != "trace" || state.Overrides["amqp"].ExpiresAt == nil {
+		t.Error("unexpected PUT override response", code, state)
+	}
+
+	code, state = levelRequestTo(t, h, http.MethodDelete, "/?identifier=amqp", "")
+	if code != http.StatusOK || len(state.Overrides) != 0 {
+		t.Error("unexpected DELETE response", code, state)
+	}
+
+	invalid := []struct {
+		method string
+		target string
+		body   string
+		code   int
+	}{
+		{method: http.MethodPut, target: "/", body: `{"level":"loud"}`, code: http.StatusBadRequest},
+		{method: http.MethodPut, target: "/", body: `{"level":"info","ttl":"soon"}`, code: http.StatusBadRequest},
+		{method: http.MethodPut, target: "/", body: `not json`, code: http.StatusBadRequest},
+		{method: http.MethodDelete, target: "/", code: http.StatusBadRequest},
+		{method: http.MethodPost, target: "/", code: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range invalid {
+		if code, _ = levelRequestTo(t, h, tt.method, tt.target, tt.body); code != tt.code {
+			t.Error("expected", tt.code, "for", tt.method, tt.body, "got", code)
+		}
+	}
+
+	if l.GetLogLevel() != "error" {
+		t.Error("expected invalid requests to leave the level untouched, got", l.GetLogLevel())
+	}
+}
@@ -6,13 +6,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 
-	"github.com/sirupsen/logrus"
+	"github.com/mikarios/golib/redact"
 )
 
+const maxTraceDepth = 100
+
 type logLevels struct {
 	PANIC   logLevelType
 	FATAL   logLevelType
@@ -24,17 +27,13 @@ type logLevels struct {
 }
 
 var (
-	showTraces = false
 	// ErrInvalidFormatter error for invalid format.
 	ErrInvalidFormatter = errors.New("invalid formatter")
-	logger              = &logrus.Logger{
-		Out:          os.Stderr,
-		Formatter:    &logrus.JSONFormatter{},
-		Hooks:        make(logrus.LevelHooks),
-		Level:        logrus.DebugLevel,
-		ExitFunc:     os.Exit,
-		ReportCaller: false,
-	}
+	// ErrInvalidSink error for a sink that cannot be used.
+	ErrInvalidSink = errors.New("invalid sink")
+	defaultMu      sync.RWMutex
+	defaultLogger  = New()
+	loggerPackage  = reflect.TypeOf(Logger{}).PkgPath() + "."
 	// LogLevels the available log levels.
 	LogLevels = logLevels{
 		PANIC:   "PANIC",
@@ -48,12 +47,14 @@ var (
 )
 
 type exportedLogger struct {
+	logger     *Logger
 	identifier string
 	level      logLevelType
 }
 
 type logLevelType string
 
+// NewLogger creates a Printf logger that writes through the default logger at the given level.
 func NewLogger(identifier string, levelType logLevelType) *exportedLogger {
 	return &exportedLogger{
 		identifier: identifier,
@@ -61,32 +62,59 @@ func NewLogger(identifier string, levelType logLevelType) *exportedLogger {
 	}
 }
 
-// Printf
-// nolint:goerr113 // Cannot declare a static error with a dynamic message.
+// Printf logs the formatted message at the level of the exportedLogger, tagged with its identifier. It makes the
+// exportedLogger usable by anything expecting a Printf logger, such as routerwrapper or the Logging interface of
+// github.com/rabbitmq/amqp091-go.
 func (expLogger *exportedLogger) Printf(format string, v ...interface{}) {
-	msgStr := fmt.Sprintf(format, v...)
-	ctx := context.Background()
+	expLogger.logAt(context.Background(), expLogger.level, fmt.Sprintf(format, v...))
+}
 
-	if expLogger.identifier != "" {
-		ctx = context.WithValue(context.Background(), Settings.IdentifierKey, expLogger.identifier)
-	}
+// logAt logs msg at level with the identifier added to ctx. Levels that need an error get one with msg as its text.
+// nolint:goerr113 // Cannot declare a static error with a dynamic message.
+func (expLogger *exportedLogger) logAt(ctx context.Context, level logLevelType, msg string, fields ...interface{}) {
+	l := expLogger.target()
+	ctx = expLogger.context(ctx, l)
+	messages := append([]interface{}{msg}, fields...)
 
-	switch expLogger.level {
+	switch level {
 	case LogLevels.PANIC:
-		Panic(ctx, errors.New(msgStr))
+		l.Panic(ctx, errors.New(msg), fields...)
 	case LogLevels.FATAL:
-		Fatal(ctx, errors.New(msgStr))
+		l.Fatal(ctx, errors.New(msg), fields...)
 	case LogLevels.ERROR:
-		Error(ctx, errors.New(msgStr))
+		l.Error(ctx, errors.New(msg), fields...)
 	case LogLevels.WARNING:
-		Warning(ctx, msgStr)
+		l.Warning(ctx, messages...)
 	case LogLevels.INFO:
-		Info(ctx, msgStr)
+		l.Info(ctx, messages...)
 	case LogLevels.DEBUG:
-		Debug(ctx, msgStr)
+		l.Debug(ctx, messages...)
 	case LogLevels.TRACE:
-		Trace(ctx, msgStr)
+		l.Trace(ctx, messages...)
+	}
+}
+
+// context returns ctx tagged with the identifier of the exportedLogger.
+func (expLogger *exportedLogger) context(ctx context.Context, l *Logger) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if expLogger.identifier == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, l.KeySettings().IdentifierKey, expLogger.identifier)
+}
+
+// target returns the logger the exportedLogger writes to. Loggers created with the package level NewLogger follow
+// whatever the default logger is at the time of logging.
+func (expLogger *exportedLogger) target() *Logger {
+	if expLogger.logger != nil {
+		return expLogger.logger
 	}
+
+	return Default()
 }
 
 type StackTrace struct {
@@ -95,155 +123,174 @@ type StackTrace struct {
 	Function string
 }
 
-// SetOutput changes the output of the logger.
+// Default returns the logger used by the package level functions.
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultLogger
+}
+
+// SetDefault replaces the logger used by the package level functions. Passing nil is a no-op.
+func SetDefault(l *Logger) {
+	if l == nil {
+		return
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	defaultLogger = l
+}
+
+// SetOutput changes the output of the default logger.
 func SetOutput(out io.Writer) {
-	logger.SetOutput(out)
+	Default().SetOutput(out)
 }
 
-// SetFormatter changes the formatter for the logs.
+// SetFormatter changes the formatter for the logs of the default logger.
 // Valid values are: "json", "text".
 func SetFormatter(formatter string) error {
-	switch strings.ToLower(formatter) {
-	case "json":
-		logger.SetFormatter(&logrus.JSONFormatter{})
-	case "text":
-		logger.SetFormatter(&logrus.TextFormatter{
-			FullTimestamp: true,
-		})
-	default:
-		return fmt.Errorf("%w : %v", ErrInvalidFormatter, formatter)
-	}
-
-	return nil
+	return Default().SetFormatter(formatter)
 }
 
-// SetLogLevel changes the level of the logging util. Acceptable strings are based on logrus.
+// SetLogLevel changes the level of the default logger. Acceptable strings are based on logrus.
 func SetLogLevel(level string) error {
-	logLevel, err := logrus.ParseLevel(level)
-	if err != nil {
-		return fmt.Errorf("could not parse error level %v : %w", level, err)
-	}
+	return Default().SetLogLevel(level)
+}
 
-	logger.SetLevel(logLevel)
+// SetLogTrace changes the showTrace option of the default logger.
+func SetLogTrace(show bool) {
+	Default().SetLogTrace(show)
+}
 
-	return nil
+// SetRedactor makes the default logger redact every entry with r. Passing nil stops redacting.
+func SetRedactor(r *redact.Redactor) {
+	Default().SetRedactor(r)
 }
 
-// SetLogTrace changes the showTrace option.
-func SetLogTrace(show bool) {
-	showTraces = show
+// SetSpanEvents makes the default logger record error, fatal and panic entries as events of the span in the context.
+func SetSpanEvents(record bool) {
+	Default().SetSpanEvents(record)
 }
 
 // Panic gets the transaction from context.
 func Panic(ctx context.Context, err error, messages ...interface{}) {
-	if err != nil {
-		l := parseMessages(ctx, err, showTraces, messages...)
-		l.Panic(err)
-	}
+	Default().Panic(ctx, err, messages...)
 }
 
 // Fatal uses ctx to extract information about the transaction in order to log it.
 // Messages are logged in Settings.MessageKey (default is "message").
 func Fatal(ctx context.Context, err error, messages ...interface{}) {
-	l := parseMessages(ctx, err, showTraces, messages...)
-	l.Fatal(err)
+	Default().Fatal(ctx, err, messages...)
 }
 
 // Error uses ctx to extract information about the transaction in order to log it.
 // Messages are logged in Settings.MessageKey (default is "message").
 func Error(ctx context.Context, err error, messages ...interface{}) {
-	l := parseMessages(ctx, err, showTraces, messages...)
-	l.Error(err)
+	Default().Error(ctx, err, messages...)
 }
 
 // Warning uses ctx to extract information about the transaction in order to log it.
 // Messages are logged in Settings.MessageKey (default is "message").
 func Warning(ctx context.Context, messages ...interface{}) {
-	l := parseMessages(ctx, nil, showTraces, messages...)
-	l.Warn()
+	Default().Warning(ctx, messages...)
 }
 
 // Info uses ctx to extract information about the transaction in order to log it.
 // Messages are logged in Settings.MessageKey (default is "message").
 func Info(ctx context.Context, messages ...interface{}) {
-	l := parseMessages(ctx, nil, false, messages...)
-	l.Info()
+	Default().Info(ctx, messages...)
 }
 
 // Debug uses ctx to extract information about the transaction in order to log it.
 // Messages are logged in Settings.MessageKey (default is "message").
 func Debug(ctx context.Context, messages ...interface{}) {
-	l := parseMessages(ctx, nil, false, messages...)
-	l.Debug()
+	Default().Debug(ctx, messages...)
 }
 
 // Trace uses ctx to extract information about the transaction in order to log it.
 // Messages are logged in Settings.MessageKey (default is "message").
 func Trace(ctx context.Context, messages ...interface{}) {
-	l := parseMessages(ctx, nil, false, messages...)
-	l.Trace()
+	Default().Trace(ctx, messages...)
 }
 
-func findTrace() []StackTrace {
-	traces := make([]StackTrace, 0)
-
-	for level := 1; level < 100; level++ {
-		fpcs := make([]uintptr, 1)
+// findTrace returns the stack trace carried by err, so that the trace points to where the error was created. If err
+// carries none, it returns the stack of the code that called the logger.
+func findTrace(err error) []StackTrace {
+	if pcs := errorStack(err); len(pcs) > 0 {
+		return framesToTrace(pcs, false)
+	}
 
-		// Skip levels to find the trace route
-		n := runtime.Callers(level, fpcs)
-		if n == 0 {
-			break
-		}
+	pcs := make([]uintptr, maxTraceDepth)
+	// Skip runtime.Callers and findTrace, the rest of the logger frames are skipped by framesToTrace.
+	n := runtime.Callers(2, pcs)
 
-		caller := runtime.FuncForPC(fpcs[0] - 1)
-		if caller == nil {
-			break
-		}
+	return framesToTrace(pcs[:n], true)
+}
 
-		file, line := caller.FileLine(fpcs[0] - 1)
-		function := caller.Name()
+// errorStack walks the chain of err and returns the stack of the innermost error that carries one. Errors carry a
+// stack if they have a StackTrace method returning a slice of program counters, like the errors of the stackerr
+// package or the ones of github.com/pkg/errors.
+func errorStack(err error) []uintptr {
+	var pcs []uintptr
 
-		traces = append(traces, StackTrace{File: file, Line: line, Function: function})
+	for ; err != nil; err = errors.Unwrap(err) {
+		if stack := stackOf(err); len(stack) > 0 {
+			pcs = stack
+		}
 	}
 
-	return traces
+	return pcs
 }
 
-func parseMessages(ctx context.Context, err error, showStackTrace bool, messages ...interface{}) *logrus.Entry {
-	if ctx == nil {
-		ctx = context.Background()
+func stackOf(err error) []uintptr {
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return nil
 	}
 
-	transactionID := ctx.Value(Settings.TransactionKey)
-	logInfo := ctx.Value(Settings.LogInfoKey)
-	identifier := ctx.Value(Settings.IdentifierKey)
-
-	l := logger.WithFields(
-		logrus.Fields{
-			string(Settings.TransactionKey): transactionID,
-			string(Settings.LogInfoKey):     logInfo,
-			string(Settings.IdentifierKey):  identifier,
-		},
-	)
-
-	for i, m := range messages {
-		if e, ok := m.(error); ok {
-			messages[i] = e.Error()
-		}
+	methodType := method.Type()
+	if methodType.NumIn() != 0 || methodType.NumOut() != 1 ||
+		methodType.Out(0).Kind() != reflect.Slice || methodType.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil
 	}
 
-	if len(messages) > 0 {
-		l = l.WithField(string(Settings.MessageKey), messages)
+	stack := method.Call(nil)[0]
+	pcs := make([]uintptr, stack.Len())
 
-		if showStackTrace {
-			l = l.WithField(string(Settings.TraceKey), findTrace())
-		}
+	for i := range pcs {
+		pcs[i] = uintptr(stack.Index(i).Uint())
 	}
 
-	if err != nil {
-		l = l.WithField(string(Settings.ErrorKey), err.Error())
+	return pcs
+}
+
+// framesToTrace resolves the program counters to StackTrace. If skipLogger is set, the frames of the logger at the
+// top of the stack are dropped.
+func framesToTrace(pcs []uintptr, skipLogger bool) []StackTrace {
+	traces := make([]StackTrace, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+
+	for more := true; more; {
+		var frame runtime.Frame
+
+		frame, more = frames.Next()
+
+		if skipLogger && isLoggerFrame(frame) {
+			continue
+		}
+
+		skipLogger = false
+
+		traces = append(traces, StackTrace{File: frame.File, Line: frame.Line, Function: frame.Function})
 	}
 
-	return l
+	return traces
+}
+
+// isLoggerFrame reports whether frame belongs to the logger itself. Tests of the package are not considered part of
+// it.
+func isLoggerFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, loggerPackage) && !strings.HasSuffix(frame.File, "_test.go")
 }
@@ -1,4 +1,4 @@
-// nolint:testpackage,paralleltest // we need access to logger var for tests.
+// nolint:testpackage,paralleltest // we need access to the default logger internals for tests.
 package logger
 
 import (
@@ -6,10 +6,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/mikarios/golib/redact"
 )
 
 var (
@@ -61,8 +66,8 @@ func TestSetLogLevel(t *testing.T) {
 		t.Error(`expected to get error while parsing level "trce"`)
 	}
 
-	if logger.GetLevel() != logrus.DebugLevel {
-		t.Error("expected logger to have default debug level. Instead got", logger.GetLevel())
+	if Default().log.GetLevel() != logrus.DebugLevel {
+		t.Error("expected logger to have default debug level. Instead got", Default().log.GetLevel())
 	}
 
 	for lvl, expectedLvl := range availableLevels {
@@ -70,8 +75,8 @@ func TestSetLogLevel(t *testing.T) {
 			t.Error("could not set level", lvl, err)
 		}
 
-		if logger.GetLevel() != expectedLvl {
-			t.Error("Expected level ", expectedLvl, " got ", logger.GetLevel())
+		if Default().log.GetLevel() != expectedLvl {
+			t.Error("Expected level ", expectedLvl, " got ", Default().log.GetLevel())
 		}
 	}
 }
@@ -444,7 +449,7 @@ func TestLogFatal(t *testing.T) {
 	// In order to test fatal we need to pass a fake exit function
 	fakeExit := func(int) {}
 
-	logger.ExitFunc = fakeExit
+	Default().log.ExitFunc = fakeExit
 
 	var buf bytes.Buffer
 
@@ -501,6 +506,45 @@ but the result was:`, logResult)
 	}
 }
 
+// slowWriter takes a while to write, like a remote log collector.
+type slowWriter struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(20 * time.Millisecond)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.String()
+}
+
+func TestLogFatalFlushesAsyncWriter(t *testing.T) {
+	out := &slowWriter{}
+
+	var atExit string
+
+	l := New()
+	l.SetOutput(NewAsyncWriter(out, 10, OverflowBlock))
+	l.log.ExitFunc = func(int) { atExit = out.String() }
+
+	l.Info(context.Background(), "buffered")
+	l.Fatal(context.Background(), errText, "last words")
+
+	if !strings.Contains(atExit, "buffered") || !strings.Contains(atExit, "last words") {
+		t.Error("expected every entry to be written before exiting, got:", atExit)
+	}
+}
+
 func TestLogPanic(t *testing.T) {
 	var buf bytes.Buffer
 
@@ -527,3 +571,115 @@ func TestLogPanic(t *testing.T) {
 
 	t.Error("should not reach this line")
 }
+
+func TestLoggerInstances(t *testing.T) {
+	var debugBuf, errorBuf bytes.Buffer
+
+	debugLogger := New()
+	debugLogger.SetOutput(&debugBuf)
+
+	errorLogger := New()
+	errorLogger.SetOutput(&errorBuf)
+	errorLogger.SetKeySettings(KeySettings{
+		TransactionKey: "requestID",
+		LogInfoKey:     "logInfo",
+		MessageKey:     "msgs",
+		ErrorKey:       "err",
+		TraceKey:       "trace",
+		IdentifierKey:  "identifier",
+	})
+
+	if err := errorLogger.SetLogLevel("error"); err != nil {
+		t.Error("could not set log level", err)
+	}
+
+	ctx := context.WithValue(context.TODO(), Settings.TransactionKey, "abc")
+	ctx = context.WithValue(ctx, myKey("requestID"), "def")
+
+	debugLogger.Debug(ctx, "test")
+	errorLogger.Debug(ctx, "test")
+
+	if !strings.Contains(debugBuf.String(), `"txID":"abc"`) {
+		t.Error("expected debug logger to log debug message, got:", debugBuf.String())
+	}
+
+	if errorBuf.Len() > 0 {
+		t.Error("expected error logger to ignore debug message, got:", errorBuf.String())
+	}
+
+	errorLogger.Error(ctx, errText, "test")
+
+	logResult := errorBuf.String()
+
+	if !strings.Contains(logResult, `"requestID":"def"`) ||
+		!strings.Contains(logResult, `"msgs":["test"]`) ||
+		!strings.Contains(logResult, `"err":"error text"`) {
+		t.Error("expected error logger to use its own key settings, got:", logResult)
+	}
+
+	if strings.Contains(debugBuf.String(), "error text") {
+		t.Error("expected debug logger output to be untouched, got:", debugBuf.String())
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	var buf bytes.Buffer
+
+	original := Default()
+	defer SetDefault(original)
+
+	l := New()
+	l.SetOutput(&buf)
+
+	SetDefault(nil)
+
+	if Default() != original {
+		t.Error("expected SetDefault(nil) to keep the current default logger")
+	}
+
+	SetDefault(l)
+
+	Info(context.TODO(), "through default")
+	NewLogger("exported", LogLevels.INFO).Printf("through %s", "printf")
+
+	logResult := buf.String()
+
+	if !strings.Contains(logResult, "through default") ||
+		!strings.Contains(logResult, "through printf") ||
+		!strings.Contains(logResult, `"identifier":"exported"`) {
+		t.Error("expected package functions to log through the new default logger, got:", logResult)
+	}
+}
+
+func TestSetRedactor(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+	l.SetRedactor(redact.Default())
+
+	l.Error(
+		context.TODO(),
+		fmt.Errorf("%w: card 4111111111111111 declined", errText),
+		"login with Bearer abc.def",
+		String("password", "p4ss"),
+		Fields{"user": map[string]interface{}{"token": "t1", "name": "john"}},
+		Any("creds", map[string]string{"password": "hunter2"}),
+		Any("f", Fields{"token": "tok123"}),
+		Any("headers", http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}}),
+		Any("keys", []string{"Bearer ghi.jkl"}),
+	)
+
+	logResult := buf.String()
+
+	secrets := []string{"4111111111111111", "abc.def", "p4ss", "t1", "hunter2", "tok123", "dXNlcjpwYXNz", "ghi.jkl"}
+	for _, secret := range secrets {
+		if strings.Contains(logResult, secret) {
+			t.Errorf("expected %v to be redacted, got: %v", secret, logResult)
+		}
+	}
+
+	if !strings.Contains(logResult, "john") || !strings.Contains(logResult, redact.DefaultReplacement) {
+		t.Error("expected only sensitive data to be redacted, got:", logResult)
+	}
+}
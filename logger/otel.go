@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanEventName is the name OpenTelemetry gives to the events describing an exception.
+const spanEventName = "exception"
+
+// SetSpanEvents makes the logger record every error, fatal and panic entry as an event of the OpenTelemetry span found
+// in the context. Spans that are not recording are left alone.
+func (l *Logger) SetSpanEvents(record bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.spanEvents = record
+}
+
+func (l *Logger) recordsSpanEvents() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.spanEvents
+}
+
+// spanFields adds the trace and span ids of the span in ctx to fields, if there is a valid one and the keys are set.
+func spanFields(ctx context.Context, keys KeySettings, fields logrus.Fields) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	if keys.TraceIDKey != "" {
+		fields[string(keys.TraceIDKey)] = sc.TraceID().String()
+	}
+
+	if keys.SpanIDKey != "" {
+		fields[string(keys.SpanIDKey)] = sc.SpanID().String()
+	}
+}
+
+// recordSpanEvent adds an exception event to the span in ctx. msg is the redacted text of err, so that the span does
+// not carry what the logs hide.
+func (l *Logger) recordSpanEvent(ctx context.Context, level logrus.Level, err error, msg string, fields logrus.Fields) {
+	if level > logrus.ErrorLevel || !l.recordsSpanEvents() {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	keys := l.KeySettings()
+
+	attrs := []attribute.KeyValue{
+		attribute.String("exception.message", msg),
+		attribute.String("log.severity", level.String()),
+	}
+
+	if err != nil {
+		attrs = append(attrs, attribute.String("exception.type", fmt.Sprintf("%T", err)))
+	}
+
+	if txID, ok := fields[string(keys.TransactionKey)].(string); ok && txID != "" {
+		attrs = append(attrs, attribute.String(string(keys.TransactionKey), txID))
+	}
+
+	span.AddEvent(spanEventName, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,153 @@
+// nolint:testpackage // checks the name of the span event, which is not exported.
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+// recordingSpan is a recording span that keeps the events added to it.
+type recordingSpan struct {
+	trace.Span
+	sc     trace.SpanContext
+	events []trace.EventConfig
+	names  []string
+}
+
+func (s *recordingSpan) SpanContext() trace.SpanContext { return s.sc }
+
+func (s *recordingSpan) IsRecording() bool { return true }
+
+func (s *recordingSpan) AddEvent(name string, options ...trace.EventOption) {
+	s.names = append(s.names, name)
+	s.events = append(s.events, trace.NewEventConfig(options...))
+}
+
+func testSpanContext(t *testing.T) trace.SpanContext {
+	t.Helper()
+
+	traceID, err := trace.TraceIDFromHex(testTraceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spanID, err := trace.SpanIDFromHex(testSpanID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+}
+
+func TestSpanFields(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+
+	ctx := context.WithValue(context.TODO(), Settings.TransactionKey, "abc")
+
+	l.Info(ctx, "no span")
+
+	entry := decodeLine(t, &buf)
+	if _, ok := entry["trace_id"]; ok {
+		t.Error("expected no trace id without a span, got:", entry)
+	}
+
+	buf.Reset()
+
+	ctx = trace.ContextWithSpanContext(ctx, testSpanContext(t))
+
+	l.Info(ctx, "with span")
+
+	entry = decodeLine(t, &buf)
+	if entry["trace_id"] != testTraceID || entry["span_id"] != testSpanID || entry["txID"] != "abc" {
+		t.Error("expected the trace and span ids next to the transaction, got:", entry)
+	}
+
+	buf.Reset()
+
+	keys := Settings
+	keys.TraceIDKey = "traceID"
+	keys.SpanIDKey = ""
+	l.SetKeySettings(keys)
+
+	l.Info(ctx, "custom keys")
+
+	entry = decodeLine(t, &buf)
+	if _, ok := entry["span_id"]; ok || entry["traceID"] != testTraceID {
+		t.Error("expected only the trace id under its own key, got:", entry)
+	}
+}
+
+func TestContextHandlerSpanFields(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	h := NewContextHandler(slog.NewJSONHandler(&buf, nil))
+	ctx := trace.ContextWithSpanContext(context.TODO(), testSpanContext(t))
+
+	slog.New(h).InfoContext(ctx, "hello")
+
+	if out := buf.String(); !strings.Contains(out, `"trace_id":"`+testTraceID+`"`) ||
+		!strings.Contains(out, `"span_id":"`+testSpanID+`"`) {
+		t.Error("expected the span ids on slog records, got:", out)
+	}
+}
+
+func TestSpanEvents(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+
+	span := &recordingSpan{Span: trace.SpanFromContext(context.TODO()), sc: testSpanContext(t)}
+	ctx := context.WithValue(context.TODO(), Settings.TransactionKey, "abc")
+	ctx = trace.ContextWithSpan(ctx, span)
+
+	l.Error(ctx, errText, "not recorded")
+
+	if len(span.events) != 0 {
+		t.Fatal("expected no span events by default, got:", span.names)
+	}
+
+	l.SetSpanEvents(true)
+	l.Warning(ctx, "a warning")
+	l.Error(ctx, errText, "recorded")
+
+	if len(span.events) != 1 || span.names[0] != spanEventName {
+		t.Fatal("expected a single exception event, got:", span.names)
+	}
+
+	attrs := attribute.NewSet(span.events[0].Attributes()...)
+
+	for key, want := range map[attribute.Key]string{
+		"exception.message": errText.Error(),
+		"log.severity":      "error",
+		"txID":              "abc",
+	} {
+		if v, ok := attrs.Value(key); !ok || v.AsString() != want {
+			t.Errorf("expected %v to be %q, got: %v", key, want, v.AsString())
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+	filePermissions  = 0o644
+	dirPermissions   = 0o755
+)
+
+// ErrInvalidRotatingFile error for a RotatingFileConfig that cannot be used.
+var ErrInvalidRotatingFile = errors.New("invalid rotating file config")
+
+// RotatingFileConfig holds the settings of a RotatingFile. A zero MaxSize or RotateEvery disables that kind of
+// rotation and a zero MaxBackups or MaxAge keeps backups regardless of count or age.
+type RotatingFileConfig struct {
+	// Filename is the file that is written to. Backups are kept next to it, named after it with the time of the
+	// rotation, e.g. app-2006-01-02T15-04-05.000.log.
+	Filename string
+	// MaxSize is the size in bytes after which the file is rotated.
+	MaxSize int64
+	// RotateEvery is the time after which the file is rotated, counting from the moment it was opened.
+	RotateEvery time.Duration
+	// MaxBackups is the number of backups to keep.
+	MaxBackups int
+	// MaxAge is how long to keep backups for.
+	MaxAge time.Duration
+	// Compress gzips the backups.
+	Compress bool
+	// ReopenOnSIGHUP reopens Filename when the process gets SIGHUP, for setups where an external tool moves it.
+	ReopenOnSIGHUP bool
+}
+
+// RotatingFile is an io.WriteCloser that writes to a file and rotates it based on its size and age. It can be used as
+// the output of a logger or of a Sink.
+type RotatingFile struct {
+	cfg RotatingFileConfig
+	// file is nil after a failed rotation or reopen, until a write opens it again.
+	file     *os.File
+	closed   bool
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+	signals  chan os.Signal
+	stop     chan struct{}
+	mill     sync.WaitGroup
+	millMu   sync.Mutex
+	mu       sync.Mutex
+}
+
+// NewRotatingFile opens cfg.Filename for appending, creating it and its directory if needed.
+func NewRotatingFile(cfg RotatingFileConfig) (*RotatingFile, error) {
+	if cfg.Filename == "" {
+		return nil, fmt.Errorf("%w : filename is required", ErrInvalidRotatingFile)
+	}
+
+	if cfg.MaxSize < 0 || cfg.RotateEvery < 0 || cfg.MaxBackups < 0 || cfg.MaxAge < 0 {
+		return nil, fmt.Errorf("%w : negative limits", ErrInvalidRotatingFile)
+	}
+
+	f := &RotatingFile{cfg: cfg, now: time.Now, stop: make(chan struct{})}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	if cfg.ReopenOnSIGHUP {
+		f.signals = make(chan os.Signal, 1)
+		signal.Notify(f.signals, syscall.SIGHUP)
+
+		go f.reopenOnSignal()
+	}
+
+	return f, nil
+}
+
+// Write writes p to the file, rotating it first if p does not fit in MaxSize or the file is older than RotateEvery.
+// When the rotation fails p is written to the current file and the rotation is tried again on the next write.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, ErrWriterClosed
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		_ = f.rotate()
+	}
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// Rotate moves the current file to a backup and opens a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return ErrWriterClosed
+	}
+
+	return f.rotate()
+}
+
+// Reopen closes the file and opens Filename again, without creating a backup. If Filename cannot be opened, the next
+// write tries again.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return ErrWriterClosed
+	}
+
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+
+	return f.open()
+}
+
+// Close closes the file and waits for the backups to be compressed and cleaned up.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+
+	if f.closed {
+		f.mu.Unlock()
+
+		return nil
+	}
+
+	err := f.closeFile()
+	f.closed = true
+
+	if f.signals != nil {
+		signal.Stop(f.signals)
+		close(f.stop)
+	}
+
+	f.mu.Unlock()
+
+	f.mill.Wait()
+
+	return err
+}
+
+func (f *RotatingFile) shouldRotate(writeSize int64) bool {
+	if f.cfg.MaxSize > 0 && f.size > 0 && f.size+writeSize > f.cfg.MaxSize {
+		return true
+	}
+
+	return f.cfg.RotateEvery > 0 && f.now().Sub(f.openedAt) >= f.cfg.RotateEvery
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.cfg.Filename), dirPermissions); err != nil {
+		return fmt.Errorf("could not create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.cfg.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, filePermissions)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("could not stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+
+	return nil
+}
+
+// closeFile closes the current file, if any. The file is forgotten even if closing it fails, since it cannot be
+// used either way.
+func (f *RotatingFile) closeFile() error {
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	if err != nil {
+		return fmt.Errorf("could not close log file: %w", err)
+	}
+
+	return nil
+}
+
+// rotate moves the current file to a backup and opens a new one. If the file cannot be moved, Filename is opened
+// again so that writing goes on.
+func (f *RotatingFile) rotate() error {
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+
+	// Rotations within the same millisecond would overwrite each other's backup.
+	t := f.now()
+	backup := f.backupName(t)
+
+	for exists(backup) || exists(backup+compressSuffix) {
+		t = t.Add(time.Millisecond)
+		backup = f.backupName(t)
+	}
+
+	if err := os.Rename(f.cfg.Filename, backup); err != nil {
+		_ = f.open()
+
+		return fmt.Errorf("could not rename log file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.mill.Add(1)
+
+	go f.millBackups(backup)
+
+	return nil
+}
+
+// millBackups compresses the new backup and removes the ones that exceed MaxBackups or MaxAge.
+func (f *RotatingFile) millBackups(backup string) {
+	defer f.mill.Done()
+
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	if f.cfg.Compress {
+		_ = compressFile(backup)
+	}
+
+	backups := f.backups()
+
+	for i, b := range backups {
+		expired := f.cfg.MaxAge > 0 && f.now().Sub(b.time) > f.cfg.MaxAge
+		extra := f.cfg.MaxBackups > 0 && i >= f.cfg.MaxBackups
+
+		if expired || extra {
+			_ = os.Remove(b.path)
+		}
+	}
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+}
+
+// backups returns the backups of the file, newest first.
+func (f *RotatingFile) backups() []backupFile {
+	dir := filepath.Dir(f.cfg.Filename)
+	prefix, ext := f.nameParts()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	backups := make([]backupFile, 0, len(entries))
+
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), compressSuffix)
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+
+		t, parseErr := time.ParseInLocation(
+			backupTimeFormat,
+			strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext),
+			time.Local,
+		)
+		if parseErr != nil {
+			continue
+		}
+
+		backups = append(backups, backupFile{path: filepath.Join(dir, entry.Name()), time: t})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+
+	return backups
+}
+
+func (f *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := f.nameParts()
+
+	return filepath.Join(filepath.Dir(f.cfg.Filename), prefix+t.Format(backupTimeFormat)+ext)
+}
+
+// nameParts splits the base name of the file into the prefix and the extension of its backups.
+func (f *RotatingFile) nameParts() (prefix, ext string) {
+	base := filepath.Base(f.cfg.Filename)
+	ext = filepath.Ext(base)
+
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func (f *RotatingFile) reopenOnSignal() {
+	for {
+		select {
+		case <-f.signals:
+			_ = f.Reopen()
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+
+	return err == nil
+}
+
+// compressFile gzips path next to it and removes it.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, filePermissions)
+	if err != nil {
+		_ = src.Close()
+
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+
+	if _, err = io.Copy(gz, src); err == nil {
+		err = gz.Close()
+	}
+
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+
+	_ = src.Close()
+
+	if err != nil {
+		_ = os.Remove(path + compressSuffix)
+
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,228 @@
+// nolint:testpackage // replaces the clock of the file to rotate it by time.
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal("could not read dir", err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func newTestRotatingFile(t *testing.T, cfg RotatingFileConfig, now *time.Time) *RotatingFile {
+	t.Helper()
+
+	f, err := NewRotatingFile(cfg)
+	if err != nil {
+		t.Fatal("could not create rotating file", err)
+	}
+
+	f.now = func() time.Time { return *now }
+	f.openedAt = *now
+
+	return f
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Date(2022, 1, 2, 3, 4, 5, 0, time.Local)
+
+	f := newTestRotatingFile(t, RotatingFileConfig{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxSize:    10,
+		MaxBackups: 2,
+	}, &now)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal("could not write", err)
+		}
+
+		now = now.Add(time.Second)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal("could not close", err)
+	}
+
+	expected := []string{"app-2022-01-02T03-04-07.000.log", "app-2022-01-02T03-04-08.000.log", "app.log"}
+	if names := listDir(t, dir); strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Error("expected", expected, "got", names)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "app.log"))
+	if string(content) != "fourth\n" {
+		t.Errorf("expected only the last line in the current file, got %q", content)
+	}
+
+	if _, err := f.Write([]byte("closed\n")); !errors.Is(err, ErrWriterClosed) {
+		t.Error("expected ErrWriterClosed, got", err)
+	}
+}
+
+func TestRotatingFileAgeAndCompression(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Date(2022, 1, 2, 3, 4, 5, 0, time.Local)
+
+	f := newTestRotatingFile(t, RotatingFileConfig{
+		Filename:    filepath.Join(dir, "app.log"),
+		RotateEvery: time.Hour,
+		MaxAge:      90 * time.Minute,
+		Compress:    true,
+	}, &now)
+
+	for i := 0; i < 3; i++ {
+		if _, err := f.Write([]byte("line\n")); err != nil {
+			t.Fatal("could not write", err)
+		}
+
+		now = now.Add(time.Hour)
+	}
+
+	if _, err := f.Write([]byte("last\n")); err != nil {
+		t.Fatal("could not write", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal("could not close", err)
+	}
+
+	// The backup rotated at 04:04:05 is older than 90 minutes at 06:04:05.
+	expected := []string{"app-2022-01-02T05-04-05.000.log.gz", "app-2022-01-02T06-04-05.000.log.gz", "app.log"}
+	if names := listDir(t, dir); strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Error("expected", expected, "got", names)
+	}
+
+	gzFile, err := os.Open(filepath.Join(dir, expected[0]))
+	if err != nil {
+		t.Fatal("could not open backup", err)
+	}
+
+	defer gzFile.Close()
+
+	gz, err := gzip.NewReader(gzFile)
+	if err != nil {
+		t.Fatal("could not read gzip", err)
+	}
+
+	if content, _ := io.ReadAll(gz); string(content) != "line\n" {
+		t.Errorf("unexpected backup content %q", content)
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	f, err := NewRotatingFile(RotatingFileConfig{Filename: filename})
+	if err != nil {
+		t.Fatal("could not create rotating file", err)
+	}
+
+	defer f.Close()
+
+	_, _ = f.Write([]byte("before\n"))
+
+	if err = os.Rename(filename, filepath.Join(dir, "moved.log")); err != nil {
+		t.Fatal("could not move file", err)
+	}
+
+	if err = f.Reopen(); err != nil {
+		t.Fatal("could not reopen", err)
+	}
+
+	_, _ = f.Write([]byte("after\n"))
+
+	if content, _ := os.ReadFile(filename); string(content) != "after\n" {
+		t.Errorf("expected a new file after reopening, got %q", content)
+	}
+
+	if _, err = NewRotatingFile(RotatingFileConfig{}); !errors.Is(err, ErrInvalidRotatingFile) {
+		t.Error("expected ErrInvalidRotatingFile, got", err)
+	}
+}
+
+func TestRotatingFileFailedRotation(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	now := time.Date(2022, 1, 2, 3, 4, 5, 0, time.Local)
+	f := newTestRotatingFile(t, RotatingFileConfig{Filename: filename}, &now)
+
+	defer f.Close()
+
+	// The file cannot be moved to a backup once it is gone.
+	if err := os.Remove(filename); err != nil {
+		t.Fatal("could not remove file", err)
+	}
+
+	if err := f.Rotate(); err == nil {
+		t.Fatal("expected the rotation to fail")
+	}
+
+	if _, err := f.Write([]byte("after rotation\n")); err != nil {
+		t.Fatal("expected writes to go on after a failed rotation, got", err)
+	}
+
+	if content, _ := os.ReadFile(filename); string(content) != "after rotation\n" {
+		t.Errorf("expected the entry in the reopened file, got %q", content)
+	}
+
+	// A directory in place of the file cannot be opened until it is removed.
+	if err := os.Rename(filename, filepath.Join(dir, "moved.log")); err != nil {
+		t.Fatal("could not move file", err)
+	}
+
+	if err := os.Mkdir(filename, dirPermissions); err != nil {
+		t.Fatal("could not create dir", err)
+	}
+
+	if err := f.Reopen(); err == nil {
+		t.Fatal("expected reopening a directory to fail")
+	}
+
+	if _, err := f.Write([]byte("lost\n")); err == nil || errors.Is(err, ErrWriterClosed) {
+		t.Fatal("expected the write to fail to open the file, got", err)
+	}
+
+	if err := os.Remove(filename); err != nil {
+		t.Fatal("could not remove dir", err)
+	}
+
+	if _, err := f.Write([]byte("reopened\n")); err != nil {
+		t.Fatal("expected the write to open the file again, got", err)
+	}
+
+	if content, _ := os.ReadFile(filename); string(content) != "reopened\n" {
+		t.Errorf("expected the entry in the file opened by the write, got %q", content)
+	}
+}
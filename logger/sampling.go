@@ -0,0 +1,230 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidSampling is returned when a SamplingConfig cannot be used.
+var ErrInvalidSampling = errors.New("invalid sampling config")
+
+// SamplingRule decides how many entries with the same level and message are logged in every interval. The First
+// entries are logged, after that every Thereafter-th entry is logged. A Thereafter of 0 drops everything after First.
+type SamplingRule struct {
+	First      int
+	Thereafter int
+}
+
+// SamplingConfig holds the sampling rules per level. Levels without a rule are never sampled, PANIC and FATAL cannot
+// have one. Every Interval the counters start over and, for every level and message with dropped entries, a summary
+// is logged at WARNING level with the number of entries dropped.
+type SamplingConfig struct {
+	Interval time.Duration
+	Levels   map[logLevelType]SamplingRule
+}
+
+type samplingKey struct {
+	level   logrus.Level
+	message string
+}
+
+type samplingCounter struct {
+	seen    int
+	dropped int
+}
+
+type sampler struct {
+	logger   *Logger
+	rules    map[logrus.Level]SamplingRule
+	counters map[samplingKey]*samplingCounter
+	stop     chan struct{}
+	done     chan struct{}
+	mu       sync.Mutex
+}
+
+// SetSampling starts sampling the entries of the default logger. Passing nil stops sampling.
+func SetSampling(cfg *SamplingConfig) error {
+	return Default().SetSampling(cfg)
+}
+
+// SetSampling starts sampling the entries of the logger according to cfg, replacing any previous config. Passing nil
+// stops sampling. The counters of a replaced config are summarised before it stops.
+func (l *Logger) SetSampling(cfg *SamplingConfig) error {
+	var s *sampler
+
+	if cfg != nil {
+		var err error
+		if s, err = newSampler(&Logger{core: l.core}, cfg); err != nil {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	previous := l.sampler
+	l.sampler = s
+	l.mu.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+
+	if s != nil {
+		go s.run(cfg.Interval)
+	}
+
+	return nil
+}
+
+func (l *Logger) currentSampler() *sampler {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.sampler
+}
+
+func newSampler(l *Logger, cfg *SamplingConfig) (*sampler, error) {
+	if cfg.Interval <= 0 {
+		return nil, fmt.Errorf("%w: interval must be positive, got %v", ErrInvalidSampling, cfg.Interval)
+	}
+
+	rules := make(map[logrus.Level]SamplingRule, len(cfg.Levels))
+
+	for levelType, rule := range cfg.Levels {
+		level, err := logrus.ParseLevel(string(levelType))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSampling, err)
+		}
+
+		if level <= logrus.FatalLevel {
+			return nil, fmt.Errorf("%w: level %v cannot be sampled", ErrInvalidSampling, levelType)
+		}
+
+		if rule.First < 0 || rule.Thereafter < 0 {
+			return nil, fmt.Errorf("%w: negative rule for level %v", ErrInvalidSampling, levelType)
+		}
+
+		rules[level] = rule
+	}
+
+	return &sampler{
+		logger:   l,
+		rules:    rules,
+		counters: make(map[samplingKey]*samplingCounter),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// allow reports whether an entry with the given level and message should be logged and counts it.
+func (s *sampler) allow(level logrus.Level, message string) bool {
+	rule, ok := s.rules[level]
+	if !ok {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := samplingKey{level: level, message: message}
+
+	counter := s.counters[key]
+	if counter == nil {
+		counter = &samplingCounter{}
+		s.counters[key] = counter
+	}
+
+	counter.seen++
+
+	if counter.seen <= rule.First {
+		return true
+	}
+
+	if rule.Thereafter > 0 && (counter.seen-rule.First)%rule.Thereafter == 0 {
+		return true
+	}
+
+	counter.dropped++
+
+	return false
+}
+
+func (s *sampler) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	defer func() {
+		ticker.Stop()
+		s.summarise()
+		close(s.done)
+	}()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.summarise()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *sampler) close() {
+	close(s.stop)
+	<-s.done
+}
+
+// summarise starts the counters over and logs how many entries were dropped for every level and message.
+func (s *sampler) summarise() {
+	s.mu.Lock()
+	counters := s.counters
+	s.counters = make(map[samplingKey]*samplingCounter)
+	s.mu.Unlock()
+
+	if !s.logger.isLevelEnabled(context.Background(), logrus.WarnLevel) {
+		return
+	}
+
+	for key, counter := range counters {
+		if counter.dropped == 0 {
+			continue
+		}
+
+		s.logger.emit(
+			context.Background(),
+			logrus.WarnLevel,
+			nil,
+			false,
+			"log sampling dropped entries",
+			String("sampledLevel", key.level.String()),
+			String("sampledMessage", key.message),
+			Int("dropped", counter.dropped),
+		)
+	}
+}
+
+// samplingMessage returns the text entries are grouped by when sampling: the error text if there is one, otherwise
+// the first message that is not a field.
+func samplingMessage(err error, messages []interface{}) string {
+	if err != nil {
+		return err.Error()
+	}
+
+	for _, m := range messages {
+		switch v := m.(type) {
+		case Field, Fields:
+			continue
+		case string:
+			return v
+		case error:
+			return v.Error()
+		default:
+			return fmt.Sprintf("%T", v)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,111 @@
+// nolint:testpackage // calls summarise instead of waiting for the sampling interval.
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	lines := make([]map[string]interface{}, 0)
+	scanner := bufio.NewScanner(buf)
+
+	for scanner.Scan() {
+		line := map[string]interface{}{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal("could not decode log line", scanner.Text(), err)
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func TestSampling(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+
+	err := l.SetSampling(&SamplingConfig{
+		Interval: time.Hour,
+		Levels:   map[logLevelType]SamplingRule{LogLevels.DEBUG: {First: 2, Thereafter: 3}},
+	})
+	if err != nil {
+		t.Fatal("could not set sampling", err)
+	}
+
+	defer func() { _ = l.SetSampling(nil) }()
+
+	for i := 0; i < 10; i++ {
+		l.Debug(context.TODO(), "Request finished", Int("i", i))
+		l.Debug(context.TODO(), "other message")
+		l.Info(context.TODO(), "Request finished")
+	}
+
+	counts := map[string]int{}
+	for _, line := range decodeLines(t, &buf) {
+		counts[line["level"].(string)+" "+line["message"].([]interface{})[0].(string)]++
+	}
+
+	// 2 first, then the 5th and the 8th.
+	if counts["debug Request finished"] != 4 ||
+		counts["debug other message"] != 4 ||
+		counts["info Request finished"] != 10 {
+		t.Error("unexpected sampling result", counts)
+	}
+
+	buf.Reset()
+	l.currentSampler().summarise()
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatal("expected a summary per sampled message, got:", lines)
+	}
+
+	for _, line := range lines {
+		if line["level"] != "warning" || line["sampledLevel"] != "debug" || line["dropped"] != float64(6) {
+			t.Error("unexpected summary", line)
+		}
+	}
+
+	buf.Reset()
+	l.Debug(context.TODO(), "Request finished")
+
+	if len(decodeLines(t, &buf)) != 1 {
+		t.Error("expected counters to start over after the summary")
+	}
+}
+
+func TestSetSamplingErrors(t *testing.T) {
+	t.Parallel()
+
+	l := New()
+
+	configs := []*SamplingConfig{
+		{Interval: 0, Levels: map[logLevelType]SamplingRule{LogLevels.DEBUG: {First: 1}}},
+		{Interval: time.Second, Levels: map[logLevelType]SamplingRule{"invalid": {First: 1}}},
+		{Interval: time.Second, Levels: map[logLevelType]SamplingRule{LogLevels.FATAL: {First: 1}}},
+		{Interval: time.Second, Levels: map[logLevelType]SamplingRule{LogLevels.INFO: {First: -1}}},
+	}
+
+	for _, cfg := range configs {
+		if err := l.SetSampling(cfg); !errors.Is(err, ErrInvalidSampling) {
+			t.Error("expected ErrInvalidSampling for", cfg, "got", err)
+		}
+	}
+
+	if l.currentSampler() != nil {
+		t.Error("expected no sampler after invalid configs")
+	}
+}
@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sink is an extra output of a logger with its own level and formatter. Level and Formatter accept the same values
+// as SetLogLevel and SetFormatter, e.g. a sink with Level "error" only gets errors, fatals and panics.
+type Sink struct {
+	Output    io.Writer
+	Level     string
+	Formatter string
+}
+
+type sink struct {
+	out io.Writer
+	// log is the logger of the entries of the sink, formatters look at its output, e.g. to decide on colours.
+	log       *logrus.Logger
+	level     logrus.Level
+	formatter logrus.Formatter
+	mu        sync.Mutex
+}
+
+// AddSink adds an extra output to the default logger. See Logger.AddSink.
+func AddSink(s Sink) error {
+	return Default().AddSink(s)
+}
+
+// AddSink adds an extra output to the logger. Every entry goes to the output set with SetOutput, at the level of the
+// logger, and to every sink whose level allows it, formatted by the formatter of the sink. Sinks keep using their
+// formatter while the logger emits through slog.
+func (l *Logger) AddSink(s Sink) error {
+	if s.Output == nil {
+		return fmt.Errorf("%w : sink without output", ErrInvalidSink)
+	}
+
+	level, err := logrus.ParseLevel(s.Level)
+	if err != nil {
+		return fmt.Errorf("could not parse sink level %v : %w", s.Level, err)
+	}
+
+	formatter, err := parseFormatter(s.Formatter)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sinks = append(l.sinks, &sink{
+		out:       s.Output,
+		log:       &logrus.Logger{Out: s.Output, Formatter: formatter, Level: level},
+		level:     level,
+		formatter: formatter,
+	})
+
+	return nil
+}
+
+// RemoveSinks removes every sink of the logger, leaving only the output set with SetOutput.
+func (l *Logger) RemoveSinks() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sinks = nil
+}
+
+func (l *Logger) currentSinks() []*sink {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.sinks
+}
+
+// isLevelEnabled reports whether the output of the logger or any of its sinks accepts level for entries logged with
+// ctx.
+func (l *Logger) isLevelEnabled(ctx context.Context, level logrus.Level) bool {
+	if level <= l.effectiveLevel(ctx) {
+		return true
+	}
+
+	for _, s := range l.currentSinks() {
+		if s.level >= level {
+			return true
+		}
+	}
+
+	return false
+}
+
+// outputs returns the output set with SetOutput followed by the outputs of the sinks.
+func (l *Logger) outputs() []io.Writer {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	outputs := make([]io.Writer, 0, len(l.sinks)+1)
+	outputs = append(outputs, l.out)
+
+	for _, s := range l.sinks {
+		outputs = append(outputs, s.out)
+	}
+
+	return outputs
+}
+
+func (l *Logger) writeSinks(ctx context.Context, level logrus.Level, msg string, fields logrus.Fields) {
+	sinks := l.currentSinks()
+	if len(sinks) == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	for _, s := range sinks {
+		if s.level < level {
+			continue
+		}
+
+		s.write(&logrus.Entry{
+			Logger:  s.log,
+			Data:    fields,
+			Time:    now,
+			Level:   level,
+			Message: msg,
+			Context: ctx,
+		})
+	}
+}
+
+func (s *sink) write(entry *logrus.Entry) {
+	b, err := s.formatter.Format(entry)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, _ = s.out.Write(b)
+}
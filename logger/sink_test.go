@@ -0,0 +1,117 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikarios/golib/logger"
+)
+
+var errFailed = errors.New("error text")
+
+func TestSinks(t *testing.T) {
+	t.Parallel()
+
+	var stdout, file, errorsOnly bytes.Buffer
+
+	l := logger.New()
+	l.SetOutput(&stdout)
+
+	if err := l.SetLogLevel("info"); err != nil {
+		t.Fatal("could not set log level", err)
+	}
+
+	if err := l.AddSink(logger.Sink{Output: &file, Level: "debug", Formatter: "text"}); err != nil {
+		t.Fatal("could not add sink", err)
+	}
+
+	if err := l.AddSink(logger.Sink{Output: &errorsOnly, Level: "error", Formatter: "json"}); err != nil {
+		t.Fatal("could not add sink", err)
+	}
+
+	ctx := context.WithValue(context.TODO(), logger.Settings.TransactionKey, "abc")
+
+	l.Debug(ctx, "debug message")
+	l.Info(ctx, "info message")
+	l.Error(ctx, errFailed, "error message")
+
+	if out := stdout.String(); strings.Contains(out, "debug message") ||
+		!strings.Contains(out, `"message":["info message"]`) ||
+		!strings.Contains(out, `"message":["error message"]`) {
+		t.Error("expected json info and error on the output, got:", out)
+	}
+
+	if out := file.String(); strings.Count(out, "\n") != 3 ||
+		!strings.Contains(out, `level=debug`) ||
+		!strings.Contains(out, `txID=abc`) ||
+		!strings.Contains(out, `msg="error text"`) {
+		t.Error("expected every entry as text on the debug sink, got:", out)
+	}
+
+	if out := errorsOnly.String(); strings.Count(out, "\n") != 1 || !strings.Contains(out, `"error":"error text"`) {
+		t.Error("expected only the error on the error sink, got:", out)
+	}
+
+	l.RemoveSinks()
+	file.Reset()
+	l.Debug(ctx, "debug message")
+
+	if file.Len() > 0 {
+		t.Error("expected no entries on removed sinks, got:", file.String())
+	}
+}
+
+func TestAddSinkErrors(t *testing.T) {
+	t.Parallel()
+
+	l := logger.New()
+
+	if err := l.AddSink(logger.Sink{Level: "debug", Formatter: "json"}); !errors.Is(err, logger.ErrInvalidSink) {
+		t.Error("expected logger.ErrInvalidSink without output, got", err)
+	}
+
+	err := l.AddSink(logger.Sink{Output: &bytes.Buffer{}, Level: "debug", Formatter: "xml"})
+	if !errors.Is(err, logger.ErrInvalidFormatter) {
+		t.Error("expected logger.ErrInvalidFormatter, got", err)
+	}
+
+	if err := l.AddSink(logger.Sink{Output: &bytes.Buffer{}, Level: "verbose", Formatter: "json"}); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+func TestSinkFormatsWithItsOwnOutput(t *testing.T) {
+	t.Parallel()
+
+	var sinkBuf bytes.Buffer
+
+	l := logger.New()
+	l.SetOutput(io.Discard)
+
+	if err := l.AddSink(logger.Sink{Output: &sinkBuf, Level: "debug", Formatter: "text"}); err != nil {
+		t.Fatal("could not add sink", err)
+	}
+
+	done := make(chan struct{})
+
+	// Changing the output of the logger must not race with the sink formatting its entries, so the entry is logged
+	// after the output changed without waiting for it.
+	go func() {
+		defer close(done)
+
+		l.SetOutput(io.Discard)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	l.Info(context.TODO(), "to the sink")
+	<-done
+
+	if out := sinkBuf.String(); strings.Contains(out, "\x1b[") || !strings.Contains(out, `message="[to the sink]"`) {
+		t.Errorf("expected the entry as text without colours, got %q", out)
+	}
+}
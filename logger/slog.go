@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Slog levels for the logrus levels that log/slog does not define.
+const (
+	SlogLevelTrace = slog.Level(-8)
+	SlogLevelFatal = slog.Level(12)
+	SlogLevelPanic = slog.Level(16)
+)
+
+type contextFieldsAddedKey struct{}
+
+// ContextHandler is a slog.Handler that adds the transaction, log info and identifier values of the context, as well
+// as the fields stored with WithFields, to every record before passing it to the next handler, the same way the
+// logger functions do.
+type ContextHandler struct {
+	next   slog.Handler
+	logger *Logger
+}
+
+// NewContextHandler wraps next so that records get the context fields named by the default logger's KeySettings.
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{next: next}
+}
+
+// NewContextHandler wraps next so that records get the context fields named by the KeySettings of l.
+func (l *Logger) NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{next: next, logger: l}
+}
+
+// NewSlogJSONHandler creates a slog.Handler that writes the same json lines as the logger does through logrus:
+// logrus level names, RFC3339 timestamps and the context fields as top level keys.
+func NewSlogJSONHandler(out io.Writer, level slog.Leveler) *ContextHandler {
+	return NewContextHandler(slog.NewJSONHandler(out, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceLogrusAttr,
+	}))
+}
+
+// SetSlogHandler makes the default logger emit through h instead of logrus. Passing nil switches back to logrus.
+func SetSlogHandler(h slog.Handler) {
+	Default().SetSlogHandler(h)
+}
+
+// Enabled reports whether the next handler handles records at the given level.
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle adds the context fields to r and passes it to the next handler. Records coming from the logger already
+// carry them, so they are passed as they are.
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil || ctx.Value(contextFieldsAddedKey{}) != nil {
+		return h.next.Handle(ctx, r)
+	}
+
+	l := h.logger
+	if l == nil {
+		l = Default()
+	}
+
+	keys := l.KeySettings()
+
+	r = r.Clone()
+
+	fields := ContextFields(ctx)
+	for _, k := range sortedKeys(fields) {
+		r.AddAttrs(slog.Any(k, fields[k]))
+	}
+
+	r.AddAttrs(
+		slog.Any(string(keys.TransactionKey), ctx.Value(keys.TransactionKey)),
+		slog.Any(string(keys.LogInfoKey), ctx.Value(keys.LogInfoKey)),
+		slog.Any(string(keys.IdentifierKey), ctx.Value(keys.IdentifierKey)),
+	)
+
+	span := logrus.Fields{}
+	spanFields(ctx, keys, span)
+
+	for _, k := range sortedKeys(span) {
+		r.AddAttrs(slog.Any(k, span[k]))
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a ContextHandler whose next handler has the given attributes.
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{next: h.next.WithAttrs(attrs), logger: h.logger}
+}
+
+// WithGroup returns a ContextHandler whose next handler has the given group.
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{next: h.next.WithGroup(name), logger: h.logger}
+}
+
+func (l *Logger) writeSlog(
+	ctx context.Context,
+	h slog.Handler,
+	level logrus.Level,
+	msg string,
+	fields logrus.Fields,
+) {
+	slogLevel := toSlogLevel(level)
+	ctx = context.WithValue(ctx, contextFieldsAddedKey{}, true)
+
+	if h.Enabled(ctx, slogLevel) {
+		r := slog.NewRecord(time.Now(), slogLevel, msg, 0)
+		for _, k := range sortedKeys(fields) {
+			r.AddAttrs(slog.Any(k, fields[k]))
+		}
+
+		_ = h.Handle(ctx, r)
+	}
+
+	if level == logrus.PanicLevel {
+		panic(msg)
+	}
+}
+
+func sortedKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func toSlogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.PanicLevel:
+		return SlogLevelPanic
+	case logrus.FatalLevel:
+		return SlogLevelFatal
+	case logrus.ErrorLevel:
+		return slog.LevelError
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	default:
+		return SlogLevelTrace
+	}
+}
+
+func toLogrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level >= SlogLevelPanic:
+		return logrus.PanicLevel
+	case level >= SlogLevelFatal:
+		return logrus.FatalLevel
+	case level >= slog.LevelError:
+		return logrus.ErrorLevel
+	case level >= slog.LevelWarn:
+		return logrus.WarnLevel
+	case level >= slog.LevelInfo:
+		return logrus.InfoLevel
+	case level >= slog.LevelDebug:
+		return logrus.DebugLevel
+	default:
+		return logrus.TraceLevel
+	}
+}
+
+func replaceLogrusAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+
+	switch a.Key {
+	case slog.TimeKey:
+		return slog.String(slog.TimeKey, a.Value.Time().Format(time.RFC3339))
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String(slog.LevelKey, toLogrusLevel(level).String())
+		}
+	}
+
+	return a
+}
@@ -0,0 +1,106 @@
+// nolint:testpackage // defines decodeLine, which the other internal tests share.
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+
+	line := map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal("could not decode log line", buf.String(), err)
+	}
+
+	delete(line, "time")
+
+	return line
+}
+
+func TestSlogHandlerMatchesLogrus(t *testing.T) {
+	t.Parallel()
+
+	var logrusBuf, slogBuf bytes.Buffer
+
+	logrusLogger := New()
+	logrusLogger.SetOutput(&logrusBuf)
+
+	slogLogger := New()
+	slogLogger.SetSlogHandler(NewSlogJSONHandler(&slogBuf, SlogLevelTrace))
+
+	ctx := context.WithValue(context.TODO(), Settings.TransactionKey, "abc")
+
+	for _, l := range []*Logger{logrusLogger, slogLogger} {
+		l.Error(ctx, errText, "test", struct {
+			TestStruct string
+		}{TestStruct: "testStruct"})
+	}
+
+	logrusLine := decodeLine(t, &logrusBuf)
+	slogLine := decodeLine(t, &slogBuf)
+
+	if !reflect.DeepEqual(logrusLine, slogLine) {
+		t.Errorf("expected identical lines.\nlogrus: %v\nslog:   %v", logrusLine, slogLine)
+	}
+
+	if slogLine["level"] != "error" || slogLine["msg"] != "error text" || slogLine["txID"] != "abc" {
+		t.Error("unexpected slog line", slogLine)
+	}
+}
+
+func TestSlogHandlerRespectsLevel(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetSlogHandler(NewSlogJSONHandler(&buf, SlogLevelTrace))
+
+	if err := l.SetLogLevel("info"); err != nil {
+		t.Error("could not set log level", err)
+	}
+
+	l.Debug(context.TODO(), "test")
+
+	if buf.Len() > 0 {
+		t.Error("expected buffer to be empty, got: ", buf.String())
+	}
+
+	l.Warning(context.TODO(), "test")
+
+	if line := decodeLine(t, &buf); line["level"] != "warning" {
+		t.Error("expected warning level, got:", line)
+	}
+}
+
+func TestContextHandler(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	ctx := context.WithValue(context.TODO(), Settings.TransactionKey, "abc")
+	ctx = context.WithValue(ctx, Settings.IdentifierKey, "my-service")
+
+	slog.New(NewSlogJSONHandler(&buf, nil)).InfoContext(ctx, "from slog", "user", 42)
+
+	line := decodeLine(t, &buf)
+
+	expected := map[string]interface{}{
+		"level":      "info",
+		"msg":        "from slog",
+		"user":       float64(42),
+		"txID":       "abc",
+		"identifier": "my-service",
+		"logInfo":    nil,
+	}
+
+	if !reflect.DeepEqual(line, expected) {
+		t.Errorf("expected %v, got %v", expected, line)
+	}
+}
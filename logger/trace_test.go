@@ -0,0 +1,103 @@
+// nolint:testpackage // reads the stack of the errors with errorStack.
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mikarios/golib/stackerr"
+)
+
+type pkgErrorsFrame uintptr
+
+type pkgErrorsStyle struct {
+	stack []pkgErrorsFrame
+}
+
+func (e *pkgErrorsStyle) Error() string {
+	return "pkg/errors style"
+}
+
+func (e *pkgErrorsStyle) StackTrace() []pkgErrorsFrame {
+	return e.stack
+}
+
+func decodeTrace(t *testing.T, buf *bytes.Buffer) []StackTrace {
+	t.Helper()
+
+	line := struct {
+		Trace []StackTrace `json:"trace"`
+	}{}
+
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal("could not decode log line", buf.String(), err)
+	}
+
+	return line.Trace
+}
+
+func createTracedError() error {
+	return stackerr.New("traced")
+}
+
+func TestTraceFromError(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+	l.SetLogTrace(true)
+
+	l.Error(context.TODO(), fmt.Errorf("wrapped: %w", createTracedError()))
+
+	trace := decodeTrace(t, &buf)
+	if len(trace) == 0 || !strings.HasSuffix(trace[0].Function, "logger.createTracedError") {
+		t.Error("expected trace to start where the error was created, got:", trace)
+	}
+
+	tracedErr := createTracedError()
+	pcs := errorStack(tracedErr)
+	frames := make([]pkgErrorsFrame, len(pcs))
+
+	for i := range pcs {
+		frames[i] = pkgErrorsFrame(pcs[i])
+	}
+
+	buf.Reset()
+	l.Warning(context.TODO(), &pkgErrorsStyle{stack: frames})
+
+	trace = decodeTrace(t, &buf)
+	if len(trace) == 0 || !strings.HasSuffix(trace[0].Function, "logger.createTracedError") {
+		t.Error("expected trace of errors with a StackTrace method among the messages, got:", trace)
+	}
+}
+
+func TestTraceFromCaller(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	l := New()
+	l.SetOutput(&buf)
+	l.SetLogTrace(true)
+
+	l.Error(context.TODO(), errText)
+
+	trace := decodeTrace(t, &buf)
+	if len(trace) == 0 || !strings.HasSuffix(trace[0].Function, "logger.TestTraceFromCaller") {
+		t.Error("expected trace without messages, starting at the caller of the logger, got:", trace)
+	}
+
+	buf.Reset()
+	l.NewLogger("exported", LogLevels.ERROR).Printf("failed")
+
+	trace = decodeTrace(t, &buf)
+	if len(trace) == 0 || !strings.HasSuffix(trace[0].Function, "logger.TestTraceFromCaller") {
+		t.Error("expected trace to skip the exported logger, got:", trace)
+	}
+}
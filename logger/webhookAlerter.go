@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultWebhookTimeout = 10 * time.Second
+
+// ErrAlertRejected is returned when the alerting backend does not accept the entries.
+var ErrAlertRejected = errors.New("alert rejected")
+
+// WebhookAlerter is an Alerter that posts entries as json to a url, in the form {"entries": [...]}.
+type WebhookAlerter struct {
+	url    string
+	client *http.Client
+	header http.Header
+}
+
+type webhookPayload struct {
+	Entries []Entry `json:"entries"`
+}
+
+// NewWebhookAlerter creates a WebhookAlerter posting to url with client. A nil client is replaced with one that times
+// out after 10 seconds.
+func NewWebhookAlerter(url string, client *http.Client) *WebhookAlerter {
+	if client == nil {
+		client = &http.Client{Timeout: defaultWebhookTimeout}
+	}
+
+	return &WebhookAlerter{
+		url:    url,
+		client: client,
+		header: http.Header{},
+	}
+}
+
+// Header adds a header, e.g. for authentication, to the requests of the WebhookAlerter.
+func (w *WebhookAlerter) Header(key, value string) *WebhookAlerter {
+	w.header.Add(key, value)
+
+	return w
+}
+
+// Alert posts entries to the url of the WebhookAlerter. Responses other than 2xx return ErrAlertRejected.
+func (w *WebhookAlerter) Alert(ctx context.Context, entries []Entry) error {
+	body, err := json.Marshal(webhookPayload{Entries: entries})
+	if err != nil {
+		return fmt.Errorf("could not marshal alert : %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not create alert request : %w", err)
+	}
+
+	for key, values := range w.header {
+		req.Header[key] = values
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send alert : %w", err)
+	}
+
+	defer res.Body.Close()
+
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w : %v", ErrAlertRejected, res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+// nolint:testpackage // decodes the requests into webhookPayload, which is not exported.
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookAlerter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		payload webhookPayload
+		token   string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("Authorization")
+
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	alerter := NewWebhookAlerter(srv.URL, srv.Client()).Header("Authorization", "Bearer token")
+	h := NewAlertHook(alerter, AlertHookConfig{Interval: time.Hour})
+
+	l := New()
+	l.SetOutput(io.Discard)
+	l.AddHook(h)
+
+	ctx := context.WithValue(context.TODO(), Settings.TransactionKey, "abc")
+	ctx = context.WithValue(ctx, Settings.IdentifierKey, "payments")
+
+	l.Error(ctx, errText, "charge failed")
+
+	if err := l.Close(); err != nil {
+		t.Fatal("could not close", err)
+	}
+
+	if token != "Bearer token" {
+		t.Error("expected the configured header, got:", token)
+	}
+
+	if len(payload.Entries) != 1 {
+		t.Fatal("expected one entry, got:", payload)
+	}
+
+	e := payload.Entries[0]
+	if e.Level != LogLevels.ERROR || e.Message != "charge failed" || e.TxID != "abc" || e.Identifier != "payments" ||
+		e.Fields["error"] != errText.Error() {
+		t.Error("unexpected entry:", e)
+	}
+}
+
+func TestWebhookAlerterRejected(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := NewWebhookAlerter(srv.URL, nil).Alert(context.TODO(), []Entry{{Level: LogLevels.ERROR}})
+	if !errors.Is(err, ErrAlertRejected) {
+		t.Error("expected ErrAlertRejected, got:", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Package loggertest records the entries of a logger in memory, so that tests can assert on what was logged without
+// parsing its output.
+package loggertest
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/mikarios/golib/logger"
+)
+
+// Recorder is a logger.Hook that keeps every entry it is fired with. It is safe for concurrent use.
+type Recorder struct {
+	entries []logger.Entry
+	mu      sync.RWMutex
+}
+
+// NewRecorder creates an empty Recorder. Add it to a logger with AddHook.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// New creates a logger that discards its output at trace level and records every entry. Tests running in parallel
+// should each use their own, since a Recorder added to the default logger sees the entries of every test. Filter
+// with TxID otherwise.
+func New() (*logger.Logger, *Recorder) {
+	l := logger.New()
+	l.SetOutput(io.Discard)
+	_ = l.SetLogLevel("trace")
+
+	r := NewRecorder()
+	l.AddHook(r)
+
+	return l, r
+}
+
+// Levels returns every level, so that the Recorder records every entry of the logger.
+func (r *Recorder) Levels() []logger.LogLevel {
+	return logger.LogLevels.All()
+}
+
+// Fire records entry.
+func (r *Recorder) Fire(_ context.Context, entry logger.Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, entry)
+}
+
+// Entries returns the recorded entries in the order they were logged.
+func (r *Recorder) Entries() []logger.Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]logger.Entry, len(r.entries))
+	copy(entries, r.entries)
+
+	return entries
+}
+
+// Len returns the number of recorded entries.
+func (r *Recorder) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.entries)
+}
+
+// Reset forgets the recorded entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+}
+
+// Filter returns the recorded entries with the given level.
+func (r *Recorder) Filter(level logger.LogLevel) []logger.Entry {
+	return r.FilterFunc(func(e logger.Entry) bool {
+		return e.Level == level
+	})
+}
+
+// TxID returns the recorded entries logged with the given transaction id.
+func (r *Recorder) TxID(txID string) []logger.Entry {
+	return r.FilterFunc(func(e logger.Entry) bool {
+		return e.TxID == txID
+	})
+}
+
+// FilterFunc returns the recorded entries for which keep returns true.
+func (r *Recorder) FilterFunc(keep func(logger.Entry) bool) []logger.Entry {
+	filtered := make([]logger.Entry, 0)
+
+	for _, e := range r.Entries() {
+		if keep(e) {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
+
+// ContainsEntry reports whether an entry with the given level and message was recorded that has every one of fields.
+// Field values are compared with reflect.DeepEqual.
+func (r *Recorder) ContainsEntry(level logger.LogLevel, message string, fields ...logger.Field) bool {
+	return len(r.FilterFunc(func(e logger.Entry) bool {
+		return e.Level == level && e.Message == message && HasFields(e, fields...)
+	})) > 0
+}
+
+// HasFields reports whether entry has every one of fields.
+func HasFields(entry logger.Entry, fields ...logger.Field) bool {
+	for _, f := range fields {
+		v, ok := entry.Fields[f.Key]
+		if !ok || !reflect.DeepEqual(v, f.Value) {
+			return false
+		}
+	}
+
+	return true
+}
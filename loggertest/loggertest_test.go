@@ -0,0 +1,119 @@
+package loggertest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/loggertest"
+	"github.com/mikarios/golib/redact"
+)
+
+var errSave = errors.New("could not save")
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	l, rec := loggertest.New()
+	ctx := context.WithValue(context.TODO(), logger.Settings.TransactionKey, "abc")
+
+	l.Info(ctx, "user", "created", logger.String("user", "john"))
+	l.Error(ctx, errSave, "saving user", logger.Int("attempt", 2))
+	l.Trace(context.TODO(), "tracing")
+
+	if rec.Len() != 3 {
+		t.Fatal("expected 3 entries, got:", rec.Entries())
+	}
+
+	errorEntries := rec.Filter(logger.LogLevels.ERROR)
+	if len(errorEntries) != 1 || !errors.Is(errorEntries[0].Error, errSave) || errorEntries[0].TxID != "abc" {
+		t.Error("expected the error entry with its error and txID, got:", errorEntries)
+	}
+
+	tests := []struct {
+		name    string
+		level   logger.LogLevel
+		message string
+		fields  []logger.Field
+		want    bool
+	}{
+		{name: "message", level: logger.LogLevels.INFO, message: "user created", want: true},
+		{
+			name:    "fields",
+			level:   logger.LogLevels.INFO,
+			message: "user created",
+			fields:  []logger.Field{logger.String("user", "john"), logger.String("txID", "abc")},
+			want:    true,
+		},
+		{name: "other level", level: logger.LogLevels.DEBUG, message: "user created", want: false},
+		{
+			name:    "other field value",
+			level:   logger.LogLevels.ERROR,
+			message: "saving user",
+			fields:  []logger.Field{logger.Int("attempt", 3)},
+			want:    false,
+		},
+		{name: "missing field", level: logger.LogLevels.TRACE, message: "tracing", fields: []logger.Field{
+			logger.String("user", "john"),
+		}, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := rec.ContainsEntry(tt.level, tt.message, tt.fields...); got != tt.want {
+				t.Errorf("ContainsEntry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecorderRedaction(t *testing.T) {
+	t.Parallel()
+
+	l, rec := loggertest.New()
+	l.SetRedactor(redact.New().Fields("password"))
+
+	l.Info(context.TODO(), "login", logger.String("password", "secret"))
+
+	if rec.ContainsEntry(logger.LogLevels.INFO, "login", logger.String("password", "secret")) {
+		t.Error("expected the recorder to see the redacted entry, got:", rec.Entries())
+	}
+}
+
+func TestRecorderConcurrency(t *testing.T) {
+	t.Parallel()
+
+	l, rec := loggertest.New()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			ctx := context.WithValue(context.TODO(), logger.Settings.TransactionKey, fmt.Sprint(i))
+			l.Info(ctx, "first")
+			l.Info(ctx, "second")
+		}(i)
+	}
+
+	wg.Wait()
+
+	if rec.Len() != 40 || len(rec.TxID("7")) != 2 {
+		t.Error("expected every entry to be recorded, got:", rec.Len())
+	}
+
+	rec.Reset()
+
+	if rec.Len() != 0 {
+		t.Error("expected no entries after Reset, got:", rec.Entries())
+	}
+}
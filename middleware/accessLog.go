@@ -0,0 +1,273 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikarios/golib/logger"
+)
+
+// defaultLatencyWindow is how many of the latest latencies an AccessLog keeps for Percentile.
+const defaultLatencyWindow = 1024
+
+// combinedTimeFormat is the time format of the Apache logs.
+const combinedTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogFormat is the format of the entries of an AccessLog.
+type AccessLogFormat int
+
+const (
+	// AccessLogJSON logs every request as an entry with a field per value, e.g. "status" and "duration".
+	AccessLogJSON AccessLogFormat = iota
+	// AccessLogCombined logs every request as an entry whose message is a line of the Apache Combined Log Format,
+	// followed by the quoted transaction id.
+	AccessLogCombined
+)
+
+// AccessLogOption configures an AccessLog.
+type AccessLogOption func(*AccessLog)
+
+// AccessLog is a middleware that logs a line for every request at info level through the logger package.
+type AccessLog struct {
+	format    AccessLogFormat
+	trusted   []netip.Prefix
+	excluded  []RequestMatcher
+	window    int
+	latencies []time.Duration
+	next      int
+	count     int
+	mu        sync.Mutex
+}
+
+// WithAccessLogFormat sets the format of the entries, AccessLogJSON by default.
+func WithAccessLogFormat(format AccessLogFormat) AccessLogOption {
+	return func(a *AccessLog) {
+		a.format = format
+	}
+}
+
+// WithTrustedProxies makes the remote ip the last address of the X-Forwarded-For header that is not one of the
+// proxies, when the request comes from one of them. Entries that are not ip addresses are skipped. Without trusted
+// proxies the remote ip is the address of the connection.
+func WithTrustedProxies(proxies ...netip.Prefix) AccessLogOption {
+	return func(a *AccessLog) {
+		a.trusted = append(a.trusted, proxies...)
+	}
+}
+
+// WithAccessLogExcluded does not log the requests matched by one of matchers, e.g. health checks.
+func WithAccessLogExcluded(matchers ...RequestMatcher) AccessLogOption {
+	return func(a *AccessLog) {
+		a.excluded = append(a.excluded, matchers...)
+	}
+}
+
+// WithLatencyWindow sets how many of the latest latencies are kept for Percentile, 1024 by default.
+func WithLatencyWindow(size int) AccessLogOption {
+	return func(a *AccessLog) {
+		if size > 0 {
+			a.window = size
+		}
+	}
+}
+
+// NewAccessLog creates an AccessLog configured by opts. Use its Middleware method as the middleware.
+func NewAccessLog(opts ...AccessLogOption) *AccessLog {
+	a := &AccessLog{window: defaultLatencyWindow}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	a.latencies = make([]time.Duration, 0, a.window)
+
+	return a
+}
+
+// Middleware logs next's requests. It can be passed to Router.Use of gorilla/mux.
+func (a *AccessLog) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if matchAny(r, a.excluded) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		start := time.Now()
+		rw := NewResponseWriter(w)
+		returned := false
+
+		defer func() {
+			duration := time.Since(start)
+			a.record(duration)
+			a.log(r, rw, rw.statusAfter(returned), start, duration)
+		}()
+
+		next.ServeHTTP(rw, r)
+
+		returned = true
+	})
+}
+
+// Percentile returns the latency below which p percent of the latest requests finished, using the nearest rank. It
+// returns 0 before any request.
+func (a *AccessLog) Percentile(p float64) time.Duration {
+	a.mu.Lock()
+	sorted := make([]time.Duration, len(a.latencies))
+	copy(sorted, a.latencies)
+	a.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+
+	return sorted[rank-1]
+}
+
+// Count returns the number of requests logged.
+func (a *AccessLog) Count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.count
+}
+
+func (a *AccessLog) record(duration time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.count++
+
+	if len(a.latencies) < a.window {
+		a.latencies = append(a.latencies, duration)
+
+		return
+	}
+
+	a.latencies[a.next] = duration
+	a.next = (a.next + 1) % a.window
+}
+
+func (a *AccessLog) log(r *http.Request, rw *ResponseWriter, status int, start time.Time, duration time.Duration) {
+	remoteIP := a.remoteIP(r)
+	ctx := r.Context()
+
+	if a.format == AccessLogCombined {
+		logger.Info(ctx, a.combined(r, rw, status, remoteIP, start))
+
+		return
+	}
+
+	logger.Info(
+		ctx,
+		"access",
+		logger.String("remote_ip", remoteIP),
+		logger.String("method", r.Method),
+		logger.String("path", r.URL.Path),
+		logger.String("protocol", r.Proto),
+		logger.Int("status", status),
+		logger.Int64("bytes", rw.BytesWritten()),
+		logger.Duration("duration", duration),
+		logger.Float64("duration_ms", float64(duration)/float64(time.Millisecond)),
+		logger.String("user_agent", r.UserAgent()),
+		logger.String("referer", r.Referer()),
+	)
+}
+
+// combined returns the Apache Combined Log Format line of the request, with the path instead of the uri so that
+// query strings are not logged, followed by the transaction id.
+func (a *AccessLog) combined(r *http.Request, rw *ResponseWriter, status int, remoteIP string, start time.Time) string {
+	size := "-"
+	if rw.BytesWritten() > 0 {
+		size = fmt.Sprint(rw.BytesWritten())
+	}
+
+	txID, _ := r.Context().Value(logger.Settings.TransactionKey).(string)
+
+	return fmt.Sprintf(
+		`%s - - [%s] "%s %s %s" %d %s %q %q %q`,
+		remoteIP,
+		start.Format(combinedTimeFormat),
+		r.Method,
+		r.URL.EscapedPath(),
+		r.Proto,
+		status,
+		size,
+		orDash(r.Referer()),
+		orDash(r.UserAgent()),
+		orDash(txID),
+	)
+}
+
+// remoteIP returns the address of the client, looking through the trusted proxies.
+func (a *AccessLog) remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !a.isTrusted(host) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+		if _, err := netip.ParseAddr(ip); err != nil {
+			// Empty or malformed entries are not addresses of anyone.
+			continue
+		}
+
+		if !a.isTrusted(ip) {
+			return ip
+		}
+
+		host = ip
+	}
+
+	return host
+}
+
+func (a *AccessLog) isTrusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, prefix := range a.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
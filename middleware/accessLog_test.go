@@ -0,0 +1,222 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/loggertest"
+	"github.com/mikarios/golib/middleware"
+)
+
+func TestAccessLogRemoteIP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{name: "untrusted connection", remoteAddr: "192.0.2.1:1234", forwarded: []string{"203.0.113.9"}, want: "192.0.2.1"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:1234", forwarded: []string{"203.0.113.9"}, want: "203.0.113.9"},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  []string{"198.51.100.7, 203.0.113.9, 10.0.0.2"},
+			want:       "203.0.113.9",
+		},
+		{
+			name:       "several headers",
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  []string{"198.51.100.7", "203.0.113.9, 10.0.0.2"},
+			want:       "203.0.113.9",
+		},
+		{
+			name:       "malformed entries are skipped",
+			remoteAddr: "10.0.0.1:1234",
+			forwarded:  []string{"203.0.113.9, not-an-ip, , 10.0.0.2"},
+			want:       "203.0.113.9",
+		},
+		{name: "only proxies", remoteAddr: "10.0.0.1:1234", forwarded: []string{"10.0.0.3, 10.0.0.2"}, want: "10.0.0.3"},
+		{name: "no header", remoteAddr: "10.0.0.1:1234", want: "10.0.0.1"},
+		{
+			name:       "ipv4 mapped proxy",
+			remoteAddr: "[::ffff:10.0.0.1]:1234",
+			forwarded:  []string{"2001:db8::1"},
+			want:       "2001:db8::1",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := middleware.NewAccessLog(middleware.WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8")))
+			h := a.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			req := newRequest(t, http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			for _, v := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			entries := logged(t, "access")
+			if len(entries) != 1 || !loggertest.HasFields(entries[0], logger.String("remote_ip", tt.want)) {
+				t.Errorf("expected remote ip %s, got: %v", tt.want, entries)
+			}
+		})
+	}
+}
+
+func TestAccessLogFormats(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		format  middleware.AccessLogFormat
+		handler http.HandlerFunc
+		check   func(t *testing.T, e logger.Entry)
+	}{
+		{
+			name:   "json",
+			format: middleware.AccessLogJSON,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("hello"))
+			},
+			check: func(t *testing.T, e logger.Entry) {
+				t.Helper()
+
+				if e.Message != "access" || e.Level != logger.LogLevels.INFO || !loggertest.HasFields(
+					e,
+					logger.String("method", http.MethodPost),
+					logger.String("path", "/orders"),
+					logger.Int("status", http.StatusCreated),
+					logger.Int64("bytes", 5),
+					logger.String("user_agent", "tester"),
+				) {
+					t.Error("unexpected entry:", e)
+				}
+			},
+		},
+		{
+			name:    "json with implicit status",
+			format:  middleware.AccessLogJSON,
+			handler: func(http.ResponseWriter, *http.Request) {},
+			check: func(t *testing.T, e logger.Entry) {
+				t.Helper()
+
+				if !loggertest.HasFields(e, logger.Int("status", http.StatusOK), logger.Int64("bytes", 0)) {
+					t.Error("expected an implicit 200, got:", e)
+				}
+			},
+		},
+		{
+			name:   "combined",
+			format: middleware.AccessLogCombined,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("hello"))
+			},
+			check: func(t *testing.T, e logger.Entry) {
+				t.Helper()
+
+				line := regexp.MustCompile(
+					`^192\.0\.2\.1 - - \[[^]]+\] "POST /orders HTTP/1\.1" 200 5 "-" "tester" "` +
+						regexp.QuoteMeta(e.TxID) + `"$`,
+				)
+				if !line.MatchString(e.Message) {
+					t.Error("unexpected line:", e.Message)
+				}
+			},
+		},
+		{
+			name:    "combined with implicit status",
+			format:  middleware.AccessLogCombined,
+			handler: func(http.ResponseWriter, *http.Request) {},
+			check: func(t *testing.T, e logger.Entry) {
+				t.Helper()
+
+				if !regexp.MustCompile(`" 200 - "-"`).MatchString(e.Message) {
+					t.Error("expected an implicit 200 without size, got:", e.Message)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a := middleware.NewAccessLog(middleware.WithAccessLogFormat(tt.format))
+
+			req := newRequest(t, http.MethodPost, "/orders?token=secret", nil)
+			req.Header.Set("User-Agent", "tester")
+			a.Middleware(tt.handler).ServeHTTP(httptest.NewRecorder(), req)
+
+			entries := recorder.TxID(txID(t))
+			if len(entries) != 1 {
+				t.Fatal("expected one entry, got:", entries)
+			}
+
+			tt.check(t, entries[0])
+		})
+	}
+}
+
+func TestAccessLogPercentile(t *testing.T) {
+	t.Parallel()
+
+	const slow = 50 * time.Millisecond
+
+	a := middleware.NewAccessLog(
+		middleware.WithLatencyWindow(10),
+		middleware.WithAccessLogExcluded(middleware.MatchPath("/health")),
+	)
+
+	if p := a.Percentile(50); p != 0 {
+		t.Error("expected 0 before any request, got:", p)
+	}
+
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(slow)
+		}
+	}))
+
+	serve := func(path string, times int) {
+		for i := 0; i < times; i++ {
+			h.ServeHTTP(httptest.NewRecorder(), newRequest(t, http.MethodGet, path, nil))
+		}
+	}
+
+	serve("/slow", 1)
+	serve("/fast", 9)
+	serve("/health", 5)
+
+	if a.Count() != 10 {
+		t.Error("expected the excluded requests not to count, got:", a.Count())
+	}
+
+	if p50, p100 := a.Percentile(50), a.Percentile(100); p50 >= slow || p100 < slow {
+		t.Errorf("expected only the 100th percentile to be slow, got p50 %v and p100 %v", p50, p100)
+	}
+
+	// The slow request falls out of the window.
+	serve("/fast", 1)
+
+	if p := a.Percentile(100); p >= slow {
+		t.Error("expected the window to drop the slow request, got:", p)
+	}
+}
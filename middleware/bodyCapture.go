@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mikarios/golib/redact"
+)
+
+// DefaultMaxBodyBytes is how much of every body LogRequestResponse logs unless told otherwise.
+const DefaultMaxBodyBytes = 64 << 10
+
+// formContentType is the content type of html form bodies, which are redacted as forms rather than json.
+const formContentType = "application/x-www-form-urlencoded"
+
+// textContentTypes are the content types, besides text/* and the +json and +xml suffixes, whose bodies get logged.
+var textContentTypes = map[string]bool{
+	"application/json":         true,
+	"application/xml":          true,
+	formContentType:            true,
+	"application/javascript":   true,
+	"application/graphql":      true,
+	"application/problem+json": true,
+}
+
+// bodyCapture keeps the first max bytes of a body and counts the rest, so that a body is logged without holding all
+// of it in memory. Bodies whose content type is not text, or that have a Content-Encoding, are only counted. Outgoing
+// request bodies are written by the transport while the call may be logged, hence the lock.
+type bodyCapture struct {
+	mu          sync.Mutex
+	buf         bytes.Buffer
+	max         int
+	total       int64
+	contentType string
+	encoding    string
+	binary      bool
+	form        bool
+}
+
+// newBodyCapture creates a bodyCapture for a body with the Content-Type and Content-Encoding of header.
+func newBodyCapture(max int, header http.Header) *bodyCapture {
+	c := &bodyCapture{max: max}
+	c.setContentType(header.Get("Content-Type"), header.Get("Content-Encoding"))
+
+	return c
+}
+
+func (c *bodyCapture) setContentType(contentType, encoding string) {
+	c.contentType = contentType
+	c.binary = !isTextContentType(contentType)
+
+	// Encoded bodies, e.g. gzip, are binary whatever their content type.
+	if encoding = strings.TrimSpace(encoding); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		c.encoding = encoding
+		c.binary = true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	c.form = mediaType == formContentType
+}
+
+// Write never fails, so that capturing never affects the request or the response.
+func (c *bodyCapture) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.total += int64(len(b))
+
+	if c.binary {
+		return len(b), nil
+	}
+
+	if room := c.max - c.buf.Len(); room > 0 {
+		if room > len(b) {
+			room = len(b)
+		}
+
+		c.buf.Write(b[:room])
+	}
+
+	return len(b), nil
+}
+
+// String returns the captured body redacted by redactor, with a marker for the bytes that were left out.
+func (c *bodyCapture) String(redactor *redact.Redactor) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.total == 0 {
+		return ""
+	}
+
+	if c.binary && c.encoding != "" {
+		return fmt.Sprintf("[%d bytes of %v encoded with %v]", c.total, c.contentType, c.encoding)
+	}
+
+	if c.binary {
+		return fmt.Sprintf("[%d bytes of %v]", c.total, c.contentType)
+	}
+
+	redactBody := redactor.JSON
+	if c.form {
+		redactBody = redactor.Form
+	}
+
+	body := string(redactBody(c.buf.Bytes()))
+
+	if left := c.total - int64(c.buf.Len()); left > 0 {
+		body += fmt.Sprintf("...[truncated %d bytes]", left)
+	}
+
+	return body
+}
+
+// isTextContentType reports whether bodies of contentType are worth logging. Bodies without a content type are
+// logged, since they usually are small text.
+func isTextContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") ||
+		strings.HasSuffix(mediaType, "+xml") ||
+		textContentTypes[mediaType]
+}
+
+// captureResponse copies the body written to rw to a bodyCapture. Its content type is known once the first bytes are
+// written, since net/http sniffs it then if it is not set.
+func captureResponse(rw *ResponseWriter, max int) *bodyCapture {
+	c := &bodyCapture{max: max}
+	started := false
+
+	rw.OnWrite(func(b []byte) {
+		if !started {
+			started = true
+
+			contentType := rw.Header().Get("Content-Type")
+			if contentType == "" {
+				contentType = http.DetectContentType(b)
+			}
+
+			c.setContentType(contentType, rw.Header().Get("Content-Encoding"))
+		}
+
+		_, _ = c.Write(b)
+	})
+
+	return c
+}
+
+// teeReadCloser copies to capture what is read from the body it wraps.
+type teeReadCloser struct {
+	io.ReadCloser
+	capture *bodyCapture
+}
+
+func (t *teeReadCloser) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = t.capture.Write(p[:n])
+	}
+
+	return n, err
+}
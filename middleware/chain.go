@@ -0,0 +1,70 @@
+package middleware
+
+import "net/http"
+
+// Chain is an ordered list of middlewares. The first one is the outermost, so it sees the request first and the
+// response last. Chains are immutable, Append and Extend return new ones, so a common chain can be extended per route:
+/*
+	api := middleware.DefaultChain(nil)
+	router.Handle("/users", api.ThenFunc(users))
+	router.Handle("/upload", api.Append(uploadLimits).ThenFunc(upload))
+*/
+type Chain struct {
+	middlewares []func(http.Handler) http.Handler
+}
+
+// NewChain creates a Chain of middlewares.
+func NewChain(middlewares ...func(http.Handler) http.Handler) Chain {
+	return Chain{middlewares: append([]func(http.Handler) http.Handler(nil), middlewares...)}
+}
+
+// DefaultChain returns the recommended stack: TransactionID first, so that everything after it logs with the
+// transaction id, then accessLog, if not nil, and NewLogRequestResponse with logOptions, which log the final status,
+// and RecoverPanic last, so that panics are logged with the transaction id and turned into the responses the loggers
+// see.
+func DefaultChain(accessLog *AccessLog, logOptions ...LogOption) Chain {
+	c := NewChain(TransactionID)
+
+	if accessLog != nil {
+		c = c.Append(accessLog.Middleware)
+	}
+
+	return c.Append(NewLogRequestResponse(logOptions...), RecoverPanic)
+}
+
+// Append returns a Chain with middlewares added after the ones of c.
+func (c Chain) Append(middlewares ...func(http.Handler) http.Handler) Chain {
+	merged := make([]func(http.Handler) http.Handler, 0, len(c.middlewares)+len(middlewares))
+	merged = append(merged, c.middlewares...)
+	merged = append(merged, middlewares...)
+
+	return Chain{middlewares: merged}
+}
+
+// Extend returns a Chain with the middlewares of other added after the ones of c.
+func (c Chain) Extend(other Chain) Chain {
+	return c.Append(other.middlewares...)
+}
+
+// Then wraps h with the middlewares of c. A nil h is replaced with http.DefaultServeMux. The method value c.Then is a
+// middleware itself, so it can be passed to Router.Use of gorilla/mux or to another Chain.
+func (c Chain) Then(h http.Handler) http.Handler {
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.middlewares[i](h)
+	}
+
+	return h
+}
+
+// ThenFunc works like Then for a handler function.
+func (c Chain) ThenFunc(fn http.HandlerFunc) http.Handler {
+	if fn == nil {
+		return c.Then(nil)
+	}
+
+	return c.Then(fn)
+}
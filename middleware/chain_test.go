@@ -0,0 +1,144 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/loggertest"
+	"github.com/mikarios/golib/middleware"
+)
+
+// tracer records the order in which its middlewares see the request and the response.
+type tracer struct {
+	calls []string
+}
+
+func (tr *tracer) middleware(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tr.calls = append(tr.calls, name+">")
+			next.ServeHTTP(w, r)
+			tr.calls = append(tr.calls, "<"+name)
+		})
+	}
+}
+
+func (tr *tracer) handler(w http.ResponseWriter, _ *http.Request) {
+	tr.calls = append(tr.calls, "handler")
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		chain func(tr *tracer) middleware.Chain
+		want  []string
+	}{
+		{
+			name: "first is outermost",
+			chain: func(tr *tracer) middleware.Chain {
+				return middleware.NewChain(tr.middleware("a"), tr.middleware("b"), tr.middleware("c"))
+			},
+			want: []string{"a>", "b>", "c>", "handler", "<c", "<b", "<a"},
+		},
+		{
+			name: "append adds inner middlewares",
+			chain: func(tr *tracer) middleware.Chain {
+				return middleware.NewChain(tr.middleware("a")).Append(tr.middleware("b"))
+			},
+			want: []string{"a>", "b>", "handler", "<b", "<a"},
+		},
+		{
+			name: "append leaves the original unchanged",
+			chain: func(tr *tracer) middleware.Chain {
+				base := middleware.NewChain(tr.middleware("a"), tr.middleware("b"))
+				_ = base.Append(tr.middleware("c"))
+
+				return base
+			},
+			want: []string{"a>", "b>", "handler", "<b", "<a"},
+		},
+		{
+			name: "appends to the same chain are independent",
+			chain: func(tr *tracer) middleware.Chain {
+				base := middleware.NewChain(tr.middleware("a"))
+				withB := base.Append(tr.middleware("b"))
+				_ = withB.Append(tr.middleware("c"))
+
+				return withB.Append(tr.middleware("d"))
+			},
+			want: []string{"a>", "b>", "d>", "handler", "<d", "<b", "<a"},
+		},
+		{
+			name: "extend",
+			chain: func(tr *tracer) middleware.Chain {
+				return middleware.NewChain(tr.middleware("a")).Extend(middleware.NewChain(tr.middleware("b")))
+			},
+			want: []string{"a>", "b>", "handler", "<b", "<a"},
+		},
+		{
+			name: "chain as a middleware",
+			chain: func(tr *tracer) middleware.Chain {
+				inner := middleware.NewChain(tr.middleware("b"), tr.middleware("c"))
+
+				return middleware.NewChain(tr.middleware("a"), inner.Then)
+			},
+			want: []string{"a>", "b>", "c>", "handler", "<c", "<b", "<a"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tr := &tracer{}
+			tt.chain(tr).ThenFunc(tr.handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if !reflect.DeepEqual(tr.calls, tt.want) {
+				t.Errorf("calls = %v, want %v", tr.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultChainLogsPanics(t *testing.T) {
+	t.Parallel()
+
+	access := middleware.NewAccessLog()
+	h := middleware.DefaultChain(access).ThenFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("txID", txID(t))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), txID(t)) ||
+		rec.Header().Get("txID") != txID(t) {
+		t.Errorf("expected a 500 with the transaction id, got %d: %q", rec.Code, rec.Body)
+	}
+
+	if entries := logged(t, "middleware recovering from panic error"); len(entries) != 1 {
+		t.Error("expected the panic to be logged with the transaction id, got:", entries)
+	}
+
+	finished := logged(t, "Request finished")
+	if len(finished) != 1 || finished[0].Level != logger.LogLevels.ERROR ||
+		!strings.Contains(finished[0].Message, `"Status":500`) {
+		t.Error("expected the request to be logged as failed, got:", finished)
+	}
+
+	accessed := logged(t, "access")
+	if len(accessed) != 1 || !loggertest.HasFields(accessed[0], logger.Int("status", http.StatusInternalServerError)) {
+		t.Error("expected the failed request in the access log, got:", accessed)
+	}
+}
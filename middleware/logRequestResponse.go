@@ -1,21 +1,27 @@
 package middleware
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/redact"
 )
 
-var ErrHijackNotSupported = errors.New("hijack not supported")
+var (
+	ErrHijackNotSupported = errors.New("hijack not supported")
+	// ErrRequestFailed is logged by NewLogRequestResponse for requests logged at error level.
+	ErrRequestFailed = errors.New("request failed")
+)
+
+func init() {
+	logger.RegisterSentinel("middleware.ErrHijackNotSupported", ErrHijackNotSupported)
+	logger.RegisterSentinel("middleware.ErrRequestFailed", ErrRequestFailed)
+}
 
 type request struct {
 	URI     string
@@ -30,86 +36,128 @@ type responseData struct {
 	Headers http.Header
 }
 
-type loggingResponseWriter struct {
-	http.ResponseWriter
-	data *responseData
+// LogRequestResponse can be used as a middleware in order to log the request as it comes towards the server,
+// as well as the answer. ExcludedURIs can be used in order to not log specific urls such as login. Logging happens on
+// the request goroutine, set a logger.AsyncWriter as the logger output to keep slow outputs off the request path.
+// Credentials, tokens and card numbers are redacted with redact.Default and bodies are cut at DefaultMaxBodyBytes.
+// Only the part of the request body the handler reads is logged, so a body the handler never reads is logged as
+// empty, where it used to be read and logged before the handler ran. Everything is logged at debug level, see
+// NewLogRequestResponse for more control.
+func LogRequestResponse(excludedURIS ...string) func(next http.Handler) http.Handler {
+	return LogRequestResponseRedacted(redact.Default(), excludedURIS...)
 }
 
-func (w *loggingResponseWriter) Write(b []byte) (int, error) {
-	size, err := w.ResponseWriter.Write(b)
-	w.data.Body += string(b)
-
-	return size, err
+// LogRequestResponseRedacted works like LogRequestResponse but redacts the uri, headers and bodies with redactor.
+// A nil redactor logs everything as it is.
+func LogRequestResponseRedacted(
+	redactor *redact.Redactor,
+	excludedURIS ...string,
+) func(next http.Handler) http.Handler {
+	return LogRequestResponseCapped(redactor, DefaultMaxBodyBytes, excludedURIS...)
 }
 
-func (w *loggingResponseWriter) WriteHeader(statusCode int) {
-	w.ResponseWriter.WriteHeader(statusCode)
-	w.data.Status = statusCode
+// LogRequestResponseCapped works like LogRequestResponseRedacted but logs up to maxBodyBytes of every body. Bodies are
+// copied as the handler reads the request and writes the response, so neither the bytes nor the streaming seen by
+// the handler and the client change, and the request body is logged when the request finishes. The bytes past
+// maxBodyBytes are only counted and bodies whose content type is not text, e.g. images or file uploads, or that are
+// compressed with a Content-Encoding, are not logged at all.
+func LogRequestResponseCapped(
+	redactor *redact.Redactor,
+	maxBodyBytes int,
+	excludedURIS ...string,
+) func(next http.Handler) http.Handler {
+	return NewLogRequestResponse(
+		WithRedactor(redactor),
+		WithMaxBodyBytes(maxBodyBytes),
+		WithExcluded(MatchURIContains(excludedURIS...)),
+		WithBodies(),
+		WithLevel(func(int) logger.LogLevel { return logger.LogLevels.DEBUG }),
+	)
 }
 
-func (w *loggingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	h, ok := w.ResponseWriter.(http.Hijacker)
-	if !ok {
-		return nil, nil, ErrHijackNotSupported
-	}
+// NewLogRequestResponse creates a middleware that logs the requests as LogRequestResponseCapped does, configured by
+// opts. By default every request is logged with its headers but without its bodies, and the finished request entry
+// has the level StatusLevel chooses, so that failed requests show up without debug logging. The request gets a field
+// scope, see logger.WithFieldScope, so the fields the handler adds with logger.WithFields are logged with the finished
+// request too.
+func NewLogRequestResponse(opts ...LogOption) func(next http.Handler) http.Handler {
+	cfg := newLogConfig(opts)
 
-	return h.Hijack()
-}
-
-// LogRequestResponse can be used as a middleware in order to log the request as it comes towards the server,
-// as well as the answer. ExcludedURIs can be used in order to not log specific urls such as login.
-func LogRequestResponse(excludedURIS ...string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			lw := &loggingResponseWriter{
-				ResponseWriter: w,
-				data:           &responseData{},
-			}
-
-			uri := r.RequestURI
+			if !cfg.logs(r) {
+				next.ServeHTTP(w, r)
 
-			for _, exclude := range excludedURIS {
-				if strings.Contains(uri, exclude) {
-					next.ServeHTTP(lw, r)
-
-					return
-				}
+				return
 			}
 
 			start := time.Now()
-			body, err := io.ReadAll(r.Body)
-			if err != nil {
-				panic(fmt.Errorf("could not read request body to log: %w", err))
-			}
-
-			_ = r.Body.Close()
+			logBody := cfg.logsBody(r)
+			r = r.WithContext(logger.WithFieldScope(r.Context()))
 
-			r.Body = io.NopCloser(bytes.NewBuffer(body))
+			reqBody := newBodyCapture(cfg.maxBodyBytes, r.Header)
+			if logBody && r.Body != nil && r.Body != http.NoBody {
+				r.Body = &teeReadCloser{ReadCloser: r.Body, capture: reqBody}
+			}
 
 			req := request{
-				Headers: r.Header,
-				URI:     uri,
-				BODY:    string(body),
+				Headers: cfg.header(r.Header),
+				URI:     cfg.uri(r),
 				Method:  r.Method,
 			}
 			reqBytes, _ := json.Marshal(req)
-			reqStr := string(reqBytes)
 
-			go logger.Debug(r.Context(), "New request:", reqStr)
+			logger.Debug(r.Context(), "New request:", string(reqBytes))
+
+			rw := NewResponseWriter(w)
+
+			resBody := newBodyCapture(cfg.maxBodyBytes, nil)
+			if logBody {
+				resBody = captureResponse(rw, cfg.maxBodyBytes)
+			}
+
+			returned := false
 
 			defer func() {
-				lw.data.Headers = lw.Header()
-				j, _ := json.Marshal(lw.data)
-				go logger.Debug(
+				status := rw.statusAfter(returned)
+				req.BODY = reqBody.String(cfg.redactor)
+				reqBytes, _ = json.Marshal(req)
+
+				j, _ := json.Marshal(responseData{
+					Body:    resBody.String(cfg.redactor),
+					Status:  status,
+					Headers: cfg.header(rw.Header()),
+				})
+				logAtLevel(
 					r.Context(),
+					cfg.level(status),
+					status,
 					"Request finished",
-					"Request:", reqStr,
+					"Request:", string(reqBytes),
 					"Response:", string(j),
 					"Execution took:", time.Since(start),
 				)
 			}()
 
-			next.ServeHTTP(lw, r)
+			next.ServeHTTP(rw, r)
+
+			returned = true
 		})
 	}
 }
+
+// logAtLevel logs messages at level. Errors are logged with ErrRequestFailed and the status.
+func logAtLevel(ctx context.Context, level logger.LogLevel, status int, messages ...interface{}) {
+	switch level {
+	case logger.LogLevels.PANIC, logger.LogLevels.FATAL, logger.LogLevels.ERROR:
+		logger.Error(ctx, fmt.Errorf("%w : %v", ErrRequestFailed, status), messages...)
+	case logger.LogLevels.WARNING:
+		logger.Warning(ctx, messages...)
+	case logger.LogLevels.INFO:
+		logger.Info(ctx, messages...)
+	case logger.LogLevels.TRACE:
+		logger.Trace(ctx, messages...)
+	default:
+		logger.Debug(ctx, messages...)
+	}
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/redact"
+)
+
+// LogOption configures the middleware created by NewLogRequestResponse.
+type LogOption func(*logConfig)
+
+type logConfig struct {
+	redactor     *redact.Redactor
+	maxBodyBytes int
+	excluded     []RequestMatcher
+	included     []RequestMatcher
+	bodies       []RequestMatcher
+	logBodies    bool
+	headers      map[string]bool
+	noQuery      bool
+	level        func(status int) logger.LogLevel
+}
+
+// StatusLevel is the default level of NewLogRequestResponse: ERROR for 5xx responses, WARNING for 4xx and DEBUG for
+// the rest.
+func StatusLevel(status int) logger.LogLevel {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return logger.LogLevels.ERROR
+	case status >= http.StatusBadRequest:
+		return logger.LogLevels.WARNING
+	default:
+		return logger.LogLevels.DEBUG
+	}
+}
+
+// WithRedactor redacts the uri, headers and bodies with redactor instead of redact.Default. A nil redactor logs
+// everything as it is.
+func WithRedactor(redactor *redact.Redactor) LogOption {
+	return func(cfg *logConfig) {
+		cfg.redactor = redactor
+	}
+}
+
+// WithMaxBodyBytes logs up to maxBodyBytes of every body instead of DefaultMaxBodyBytes.
+func WithMaxBodyBytes(maxBodyBytes int) LogOption {
+	return func(cfg *logConfig) {
+		cfg.maxBodyBytes = maxBodyBytes
+	}
+}
+
+// WithExcluded does not log the requests matched by one of matchers. Exclusions win over WithIncluded.
+func WithExcluded(matchers ...RequestMatcher) LogOption {
+	return func(cfg *logConfig) {
+		cfg.excluded = append(cfg.excluded, matchers...)
+	}
+}
+
+// WithIncluded only logs the requests matched by one of matchers.
+func WithIncluded(matchers ...RequestMatcher) LogOption {
+	return func(cfg *logConfig) {
+		cfg.included = append(cfg.included, matchers...)
+	}
+}
+
+// WithBodies logs the bodies of the requests matched by one of matchers, or of every request without matchers.
+// Bodies are not logged otherwise.
+func WithBodies(matchers ...RequestMatcher) LogOption {
+	return func(cfg *logConfig) {
+		cfg.logBodies = true
+		cfg.bodies = append(cfg.bodies, matchers...)
+	}
+}
+
+// WithHeaders only logs the headers with the given names. Every header is logged otherwise.
+func WithHeaders(names ...string) LogOption {
+	return func(cfg *logConfig) {
+		if cfg.headers == nil {
+			cfg.headers = make(map[string]bool, len(names))
+		}
+
+		for _, name := range names {
+			cfg.headers[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// WithQuery decides whether the logged uri has its query string, which it has by default.
+func WithQuery(include bool) LogOption {
+	return func(cfg *logConfig) {
+		cfg.noQuery = !include
+	}
+}
+
+// WithLevel chooses the level of the finished request entry from the response status instead of StatusLevel. PANIC
+// and FATAL are logged as ERROR.
+func WithLevel(level func(status int) logger.LogLevel) LogOption {
+	return func(cfg *logConfig) {
+		cfg.level = level
+	}
+}
+
+func newLogConfig(opts []LogOption) *logConfig {
+	cfg := &logConfig{
+		redactor:     redact.Default(),
+		maxBodyBytes: DefaultMaxBodyBytes,
+		level:        StatusLevel,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+func (cfg *logConfig) logs(r *http.Request) bool {
+	if matchAny(r, cfg.excluded) {
+		return false
+	}
+
+	return len(cfg.included) == 0 || matchAny(r, cfg.included)
+}
+
+func (cfg *logConfig) logsBody(r *http.Request) bool {
+	return cfg.logBodies && (len(cfg.bodies) == 0 || matchAny(r, cfg.bodies))
+}
+
+// header returns the allowed headers of header, redacted.
+func (cfg *logConfig) header(header http.Header) http.Header {
+	if cfg.headers == nil {
+		return cfg.redactor.Header(header)
+	}
+
+	allowed := make(http.Header, len(cfg.headers))
+
+	for name, values := range header {
+		if cfg.headers[http.CanonicalHeaderKey(name)] {
+			allowed[name] = values
+		}
+	}
+
+	return cfg.redactor.Header(allowed)
+}
+
+// uri returns the uri of r, redacted and without its query string if so configured.
+func (cfg *logConfig) uri(r *http.Request) string {
+	uri := r.RequestURI
+	if cfg.noQuery {
+		uri = r.URL.EscapedPath()
+	}
+
+	return cfg.redactor.String(uri)
+}
@@ -0,0 +1,347 @@
+package middleware_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/loggertest"
+	"github.com/mikarios/golib/middleware"
+	"github.com/mikarios/golib/redact"
+)
+
+func TestLogRequestResponseHandlerFields(t *testing.T) {
+	t.Parallel()
+
+	h := middleware.NewLogRequestResponse()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := logger.WithFields(r.Context(), logger.String("userID", "u-1"))
+		logger.Info(ctx, "authenticated")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), newRequest(t, http.MethodGet, "/users/me", nil))
+
+	finished := logged(t, "Request finished")
+	if len(finished) != 1 || !loggertest.HasFields(finished[0], logger.String("userID", "u-1")) {
+		t.Error("expected the field of the handler on the finished request, got:", finished)
+	}
+}
+
+func TestLogRequestResponseBodies(t *testing.T) {
+	t.Parallel()
+
+	body := bytes.Repeat([]byte(`{"password":"p4ss"}`), 100)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		read        bool
+		wantRequest string
+	}{
+		{
+			name:        "truncated text",
+			contentType: "application/json",
+			body:        body,
+			read:        true,
+			wantRequest: `\"[REDACTED]\"...[truncated 1770 bytes]`,
+		},
+		{
+			name:        "binary is only counted",
+			contentType: "image/png",
+			body:        []byte("\x89PNG\r\n\x1a\n\x00\x00"),
+			read:        true,
+			wantRequest: `[10 bytes of image/png]`,
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        []byte("username=bob&password=hunter2"),
+			read:        true,
+			wantRequest: `"BODY":"username=bob\u0026password=%5BREDACTED%5D"`,
+		},
+		{
+			name:        "unread body is logged empty",
+			contentType: "text/plain",
+			body:        []byte("never read"),
+			wantRequest: `"BODY":""`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var received []byte
+
+			mw := middleware.LogRequestResponseCapped(redact.Default(), 130)
+			h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.read {
+					received, _ = io.ReadAll(r.Body)
+				}
+
+				w.Header().Set("Content-Type", "image/png")
+				_, _ = w.Write([]byte("\x89PNG\r\n\x1a\n"))
+			}))
+
+			req := newRequest(t, http.MethodPost, "/upload", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.read && !bytes.Equal(received, tt.body) {
+				t.Error("expected the handler to read the body as it was sent")
+			}
+
+			finished := logged(t, "Request finished")
+			if len(finished) != 1 {
+				t.Fatal("expected one finished entry, got:", finished)
+			}
+
+			if msg := finished[0].Message; !strings.Contains(msg, tt.wantRequest) ||
+				!strings.Contains(msg, `[8 bytes of image/png]`) {
+				t.Errorf("expected %s in the request and a counted response, got: %v", tt.wantRequest, msg)
+			}
+		})
+	}
+}
+
+func TestLogRequestResponseEncodedBodies(t *testing.T) {
+	t.Parallel()
+
+	var gz bytes.Buffer
+
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(`{"password":"p4ss"}`))
+	_ = zw.Close()
+
+	encoded := gz.Bytes()
+
+	h := middleware.NewLogRequestResponse(middleware.WithBodies())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(io.Discard, r.Body)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(encoded)
+		}),
+	)
+
+	req := newRequest(t, http.MethodPost, "/", bytes.NewReader(encoded))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	finished := logged(t, "Request finished")
+	if len(finished) != 1 {
+		t.Fatal("expected one finished request, got:", finished)
+	}
+
+	want := fmt.Sprintf("[%d bytes of application/json encoded with gzip]", len(encoded))
+	if strings.Count(finished[0].Message, want) != 2 {
+		t.Errorf("expected both bodies to be counted as %s, got: %s", want, finished[0].Message)
+	}
+}
+
+func TestLogRequestResponseStreaming(t *testing.T) {
+	t.Parallel()
+
+	next := make(chan struct{})
+
+	h := middleware.LogRequestResponse()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			_, _ = fmt.Fprintf(w, "chunk %d\n", i)
+			http.NewResponseController(w).Flush()
+
+			select {
+			case <-next:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	res, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal("could not call server", err)
+	}
+
+	defer res.Body.Close()
+
+	reader := bufio.NewReader(res.Body)
+
+	// Every chunk has to arrive before the handler writes the next one.
+	for i := 0; i < 3; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil || line != fmt.Sprintf("chunk %d\n", i) {
+			t.Fatalf("expected chunk %d, got %q %v", i, line, err)
+		}
+
+		next <- struct{}{}
+	}
+}
+
+func TestNewLogRequestResponseOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		opts        []middleware.LogOption
+		method      string
+		target      string
+		status      int
+		wantLogged  bool
+		wantLevel   logger.LogLevel
+		wantIn      []string
+		wantNotIn   []string
+		wantErrorIs error
+	}{
+		{
+			name:       "included",
+			opts:       []middleware.LogOption{middleware.WithIncluded(middleware.MatchPath("/api/*"))},
+			method:     http.MethodGet,
+			target:     "/api/users",
+			status:     http.StatusOK,
+			wantLogged: true,
+			wantLevel:  logger.LogLevels.DEBUG,
+		},
+		{
+			name:   "not included",
+			opts:   []middleware.LogOption{middleware.WithIncluded(middleware.MatchPath("/api/*"))},
+			method: http.MethodGet,
+			target: "/health",
+			status: http.StatusOK,
+		},
+		{
+			name: "exclusions win over inclusions",
+			opts: []middleware.LogOption{
+				middleware.WithExcluded(middleware.MatchMethods(http.MethodDelete)),
+				middleware.WithIncluded(middleware.MatchPath("/api/*")),
+			},
+			method: http.MethodDelete,
+			target: "/api/users",
+			status: http.StatusOK,
+		},
+		{
+			name:       "header allow-list",
+			opts:       []middleware.LogOption{middleware.WithHeaders("x-request-id")},
+			method:     http.MethodGet,
+			target:     "/",
+			status:     http.StatusOK,
+			wantLogged: true,
+			wantLevel:  logger.LogLevels.DEBUG,
+			wantIn:     []string{`"X-Request-Id":["r-1"]`},
+			wantNotIn:  []string{"Accept-Language"},
+		},
+		{
+			name:       "without query",
+			opts:       []middleware.LogOption{middleware.WithQuery(false)},
+			method:     http.MethodGet,
+			target:     "/search?q=secret",
+			status:     http.StatusOK,
+			wantLogged: true,
+			wantLevel:  logger.LogLevels.DEBUG,
+			wantIn:     []string{`"URI":"/search"`},
+			wantNotIn:  []string{"q=secret"},
+		},
+		{
+			name:       "with query",
+			method:     http.MethodGet,
+			target:     "/search?q=term",
+			status:     http.StatusOK,
+			wantLogged: true,
+			wantLevel:  logger.LogLevels.DEBUG,
+			wantIn:     []string{`"URI":"/search?q=term"`},
+		},
+		{
+			name:        "server error",
+			method:      http.MethodPost,
+			target:      "/orders",
+			status:      http.StatusBadGateway,
+			wantLogged:  true,
+			wantLevel:   logger.LogLevels.ERROR,
+			wantErrorIs: middleware.ErrRequestFailed,
+		},
+		{
+			name:       "implicit status",
+			method:     http.MethodGet,
+			target:     "/",
+			wantLogged: true,
+			wantLevel:  logger.LogLevels.DEBUG,
+			wantIn:     []string{`"Status":200`},
+		},
+		{
+			name:       "client error",
+			method:     http.MethodPost,
+			target:     "/orders",
+			status:     http.StatusNotFound,
+			wantLogged: true,
+			wantLevel:  logger.LogLevels.WARNING,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := middleware.NewLogRequestResponse(tt.opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+			}))
+
+			req := newRequest(t, tt.method, tt.target, nil)
+			req.Header.Set("X-Request-Id", "r-1")
+			req.Header.Set("Accept-Language", "en")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			finished := logged(t, "Request finished")
+			if !tt.wantLogged {
+				if len(finished) != 0 || len(logged(t, "New request:")) != 0 {
+					t.Error("expected the request not to be logged, got:", finished)
+				}
+
+				return
+			}
+
+			if len(finished) != 1 {
+				t.Fatal("expected one finished request, got:", finished)
+			}
+
+			e := finished[0]
+			if e.Level != tt.wantLevel {
+				t.Errorf("level = %v, want %v", e.Level, tt.wantLevel)
+			}
+
+			if tt.wantErrorIs != nil && !errors.Is(e.Error, tt.wantErrorIs) {
+				t.Errorf("error = %v, want %v", e.Error, tt.wantErrorIs)
+			}
+
+			for _, s := range tt.wantIn {
+				if !strings.Contains(e.Message, s) {
+					t.Errorf("expected %s in %s", s, e.Message)
+				}
+			}
+
+			for _, s := range tt.wantNotIn {
+				if strings.Contains(e.Message, s) {
+					t.Errorf("did not expect %s in %s", s, e.Message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// RequestMatcher decides whether a middleware option applies to a request.
+type RequestMatcher func(r *http.Request) bool
+
+// MatchMethods matches requests with one of the methods.
+func MatchMethods(methods ...string) RequestMatcher {
+	return func(r *http.Request) bool {
+		for _, method := range methods {
+			if strings.EqualFold(r.Method, method) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// MatchPath matches requests whose path matches pattern, as path.Match does, e.g. "/users/*/orders". Invalid
+// patterns match nothing.
+func MatchPath(pattern string) RequestMatcher {
+	return func(r *http.Request) bool {
+		matched, err := path.Match(pattern, r.URL.Path)
+
+		return err == nil && matched
+	}
+}
+
+// MatchPathRegexp matches requests whose path matches re.
+func MatchPathRegexp(re *regexp.Regexp) RequestMatcher {
+	return func(r *http.Request) bool {
+		return re.MatchString(r.URL.Path)
+	}
+}
+
+// MatchURIContains matches requests whose uri, query included, contains one of the substrings.
+func MatchURIContains(substrings ...string) RequestMatcher {
+	return func(r *http.Request) bool {
+		for _, s := range substrings {
+			if strings.Contains(r.RequestURI, s) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// MatchRouteName matches requests routed by gorilla/mux to a route with one of the names. The route is only known to
+// middlewares added with Router.Use or Route.Handler, not to ones wrapping the router.
+func MatchRouteName(names ...string) RequestMatcher {
+	return func(r *http.Request) bool {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			return false
+		}
+
+		for _, name := range names {
+			if route.GetName() == name {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// MatchAll matches requests matched by every one of matchers.
+func MatchAll(matchers ...RequestMatcher) RequestMatcher {
+	return func(r *http.Request) bool {
+		for _, m := range matchers {
+			if !m(r) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// matchAny reports whether one of matchers matches r.
+func matchAny(r *http.Request, matchers []RequestMatcher) bool {
+	for _, m := range matchers {
+		if m(r) {
+			return true
+		}
+	}
+
+	return false
+}
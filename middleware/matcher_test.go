@@ -0,0 +1,108 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mikarios/golib/middleware"
+)
+
+func TestMatchers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		matcher middleware.RequestMatcher
+		method  string
+		target  string
+		want    bool
+	}{
+		{name: "method", matcher: middleware.MatchMethods("post", "PUT"), method: http.MethodPost, target: "/", want: true},
+		{name: "other method", matcher: middleware.MatchMethods("PUT"), method: http.MethodGet, target: "/", want: false},
+		{
+			name:    "path pattern",
+			matcher: middleware.MatchPath("/users/*/orders"),
+			method:  http.MethodGet,
+			target:  "/users/1/orders?page=2",
+			want:    true,
+		},
+		{
+			name:    "path pattern is not a prefix",
+			matcher: middleware.MatchPath("/users/*"),
+			method:  http.MethodGet,
+			target:  "/users/1/orders",
+			want:    false,
+		},
+		{
+			name:    "invalid pattern",
+			matcher: middleware.MatchPath("/users/["),
+			method:  http.MethodGet,
+			target:  "/users/x",
+			want:    false,
+		},
+		{
+			name:    "path regexp",
+			matcher: middleware.MatchPathRegexp(regexp.MustCompile(`^/v\d+/`)),
+			method:  http.MethodGet,
+			target:  "/v2/users",
+			want:    true,
+		},
+		{
+			name:    "uri with query",
+			matcher: middleware.MatchURIContains("/login", "token="),
+			method:  http.MethodGet,
+			target:  "/callback?token=abc",
+			want:    true,
+		},
+		{
+			name:    "all",
+			matcher: middleware.MatchAll(middleware.MatchMethods("GET"), middleware.MatchPath("/health")),
+			method:  http.MethodPost,
+			target:  "/health",
+			want:    false,
+		},
+		{name: "route name without router", matcher: middleware.MatchRouteName("health"), target: "/health", want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.matcher(httptest.NewRequest(tt.method, tt.target, nil)); got != tt.want {
+				t.Errorf("matcher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRouteName(t *testing.T) {
+	t.Parallel()
+
+	var matched []bool
+
+	router := mux.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			matched = append(matched, middleware.MatchRouteName("user")(r))
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	ok := func(http.ResponseWriter, *http.Request) {}
+	router.HandleFunc("/users/{id}", ok).Name("user")
+	router.HandleFunc("/health", ok).Name("health")
+
+	for _, target := range []string{"/users/1", "/health"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	if len(matched) != 2 || !matched[0] || matched[1] {
+		t.Error("expected only the named route to match, got:", matched)
+	}
+}
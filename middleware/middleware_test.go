@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/loggertest"
+)
+
+var (
+	// recorder records the entries of the default logger, which the middlewares log with. Tests run in parallel, so
+	// each one logs with its own transaction id, see txID, and only looks at its own entries.
+	recorder *loggertest.Recorder
+
+	txIDsMu sync.Mutex
+	txIDs   = map[*testing.T]string{}
+)
+
+func TestMain(m *testing.M) {
+	l, r := loggertest.New()
+	logger.SetDefault(l)
+
+	recorder = r
+
+	os.Exit(m.Run())
+}
+
+// txID returns the transaction id of t, made of its name and a counter so that it stays unique with -count.
+func txID(t *testing.T) string {
+	t.Helper()
+
+	txIDsMu.Lock()
+	defer txIDsMu.Unlock()
+
+	if id, ok := txIDs[t]; ok {
+		return id
+	}
+
+	id := t.Name() + "-" + strconv.Itoa(len(txIDs))
+	txIDs[t] = id
+
+	return id
+}
+
+// newRequest creates a request whose context has the transaction id of t.
+func newRequest(t *testing.T, method, target string, body io.Reader) *http.Request {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, body)
+
+	return req.WithContext(context.WithValue(req.Context(), logger.Settings.TransactionKey, txID(t)))
+}
+
+// logged returns the entries logged with the transaction id of t whose message starts with prefix.
+func logged(t *testing.T, prefix string) []logger.Entry {
+	t.Helper()
+
+	id := txID(t)
+
+	return recorder.FilterFunc(func(e logger.Entry) bool {
+		return e.TxID == id && strings.HasPrefix(e.Message, prefix)
+	})
+}
+
+// get sends a GET request to url and returns the response, whose body the caller closes.
+func get(t *testing.T, url string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal("invalid request:", err)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal("request failed:", err)
+	}
+
+	return res
+}
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"runtime/debug"
 
 	"github.com/mikarios/golib/logger"
 )
@@ -11,18 +14,178 @@ import (
 // ErrRecover is returned when we recover from an error.
 var ErrRecover = errors.New("caught panic stacktrace")
 
-// RecoverPanic is operating as middleware to handle any panic that may occur.
+func init() {
+	logger.RegisterSentinel("middleware.ErrRecover", ErrRecover)
+}
+
+const internalServerError = "Internal server error"
+
+// PanicReport describes a panic recovered by RecoverPanic.
+type PanicReport struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Err is ErrRecover wrapping Value, and wrapping it with %w too when Value is an error.
+	Err error
+	// Stack is the stack of the goroutine that panicked, the one of the handler when it panicked under Timeout.
+	Stack []byte
+	TxID  string
+	// Committed is true when the handler had already started the response, which then cannot be replaced.
+	Committed bool
+}
+
+// ErrorRenderer writes the response of a request whose handler panicked.
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, report PanicReport)
+
+// RecoverOption configures the middleware created by NewRecoverPanic.
+type RecoverOption func(*recoverConfig)
+
+type recoverConfig struct {
+	renderer ErrorRenderer
+	onPanic  []func(ctx context.Context, report PanicReport)
+}
+
+// WithRenderer writes the error responses with renderer instead of TextRenderer, e.g. with JSONRenderer or
+// ProblemRenderer.
+func WithRenderer(renderer ErrorRenderer) RecoverOption {
+	return func(cfg *recoverConfig) {
+		cfg.renderer = renderer
+	}
+}
+
+// WithPanicCallback calls onPanic with every recovered panic after logging it, e.g. to count panics or report them.
+func WithPanicCallback(onPanic func(ctx context.Context, report PanicReport)) RecoverOption {
+	return func(cfg *recoverConfig) {
+		cfg.onPanic = append(cfg.onPanic, onPanic)
+	}
+}
+
+// RecoverPanic is operating as middleware to handle any panic that may occur. It works like NewRecoverPanic without
+// options.
 func RecoverPanic(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-
-		defer func() {
-			if err := recover(); err != nil {
-				logger.Error(ctx, fmt.Errorf("%w: %v", ErrRecover, err), "middleware recovering from panic error")
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
-			}
-		}()
-
-		next.ServeHTTP(w, r)
-	})
+	return NewRecoverPanic()(next)
+}
+
+// NewRecoverPanic creates a middleware that recovers the panics of the handler and logs them with the stack of the
+// goroutine and the transaction id. The error response is written by the renderer of the options unless the handler
+// had already started the response, in which case the connection is aborted so that the client does not take a cut
+// response for a complete one. Panics with http.ErrAbortHandler are passed on untouched, since they are the way to
+// abort a response on purpose.
+func NewRecoverPanic(opts ...RecoverOption) func(next http.Handler) http.Handler {
+	cfg := &recoverConfig{renderer: TextRenderer}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := NewResponseWriter(w)
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+
+				if v == http.ErrAbortHandler { // nolint:errorlint,goerr113 // net/http compares it the same way.
+					panic(v)
+				}
+
+				stack := debug.Stack()
+				if p, ok := v.(handlerPanic); ok {
+					v, stack = p.value, p.stack
+				}
+
+				cfg.recovered(r, rw, v, stack)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+func (cfg *recoverConfig) recovered(r *http.Request, rw *ResponseWriter, v interface{}, stack []byte) {
+	ctx := r.Context()
+	txID, _ := ctx.Value(logger.Settings.TransactionKey).(string)
+
+	report := PanicReport{
+		Value:     v,
+		Err:       panicError(v),
+		Stack:     stack,
+		TxID:      txID,
+		Committed: rw.Written(),
+	}
+
+	logger.Error(
+		ctx,
+		report.Err,
+		"middleware recovering from panic error",
+		logger.String("stack", string(stack)),
+		logger.Bool("response_committed", report.Committed),
+	)
+
+	for _, onPanic := range cfg.onPanic {
+		onPanic(ctx, report)
+	}
+
+	if report.Committed {
+		panic(http.ErrAbortHandler)
+	}
+
+	cfg.renderer(rw, r, report)
+}
+
+// panicError returns ErrRecover wrapping v, and wrapping it with %w too when v is an error.
+func panicError(v interface{}) error {
+	if err, ok := v.(error); ok {
+		return fmt.Errorf("%w: %w", ErrRecover, err)
+	}
+
+	return fmt.Errorf("%w: %v", ErrRecover, v)
+}
+
+// TextRenderer writes a plain text 500 response with the transaction id.
+func TextRenderer(w http.ResponseWriter, _ *http.Request, report PanicReport) {
+	msg := internalServerError
+	if report.TxID != "" {
+		msg += ", transaction " + report.TxID
+	}
+
+	http.Error(w, msg, http.StatusInternalServerError)
+}
+
+// JSONRenderer writes a json 500 response of the form {"error": "Internal server error", "txID": "..."}, the key
+// of the transaction id being logger.Settings.TransactionKey.
+func JSONRenderer(w http.ResponseWriter, _ *http.Request, report PanicReport) {
+	body := map[string]string{"error": internalServerError}
+	if report.TxID != "" {
+		body[string(logger.Settings.TransactionKey)] = report.TxID
+	}
+
+	writeJSON(w, "application/json", body)
+}
+
+// ProblemRenderer writes a 500 response in the problem details format of RFC 9457, with the transaction id as an
+// extension named after logger.Settings.TransactionKey.
+func ProblemRenderer(w http.ResponseWriter, r *http.Request, report PanicReport) {
+	body := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    http.StatusText(http.StatusInternalServerError),
+		"status":   http.StatusInternalServerError,
+		"instance": r.URL.Path,
+	}
+
+	if report.TxID != "" {
+		body[string(logger.Settings.TransactionKey)] = report.TxID
+	}
+
+	writeJSON(w, "application/problem+json", body)
+}
+
+func writeJSON(w http.ResponseWriter, contentType string, body interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusInternalServerError)
+
+	_ = json.NewEncoder(w).Encode(body)
 }
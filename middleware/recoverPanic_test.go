@@ -0,0 +1,204 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/loggertest"
+	"github.com/mikarios/golib/middleware"
+)
+
+var errBoom = errors.New("boom")
+
+// statusRecorder records every status written, to show that no second one is sent.
+type statusRecorder struct {
+	*httptest.ResponseRecorder
+	statuses []int
+}
+
+func (s *statusRecorder) WriteHeader(statusCode int) {
+	s.statuses = append(s.statuses, statusCode)
+	s.ResponseRecorder.WriteHeader(statusCode)
+}
+
+// panicOf calls f and returns the value it panicked with, nil if it did not.
+func panicOf(f func()) (v interface{}) {
+	defer func() {
+		v = recover()
+	}()
+
+	f()
+
+	return nil
+}
+
+func TestRecoverPanicRenderers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		renderer        middleware.ErrorRenderer
+		withoutTxID     bool
+		wantContentType string
+		wantBody        func(txID string) string
+	}{
+		{
+			name:            "text",
+			renderer:        middleware.TextRenderer,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        func(txID string) string { return "Internal server error, transaction " + txID + "\n" },
+		},
+		{
+			name:            "text without transaction id",
+			renderer:        middleware.TextRenderer,
+			withoutTxID:     true,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        func(string) string { return "Internal server error\n" },
+		},
+		{
+			name:            "json",
+			renderer:        middleware.JSONRenderer,
+			wantContentType: "application/json",
+			wantBody: func(txID string) string {
+				return `{"error":"Internal server error","txID":"` + txID + `"}` + "\n"
+			},
+		},
+		{
+			name:            "problem",
+			renderer:        middleware.ProblemRenderer,
+			wantContentType: "application/problem+json",
+			wantBody: func(txID string) string {
+				return `{"instance":"/orders","status":500,"title":"Internal Server Error","txID":"` + txID +
+					`","type":"about:blank"}` + "\n"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := middleware.NewRecoverPanic(middleware.WithRenderer(tt.renderer))(
+				http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") }),
+			)
+
+			req := newRequest(t, http.MethodGet, "/orders", nil)
+			id := txID(t)
+
+			if tt.withoutTxID {
+				req = httptest.NewRequest(http.MethodGet, "/orders", nil)
+				id = ""
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf("got %d with content type %q", rec.Code, rec.Header().Get("Content-Type"))
+			}
+
+			if want := tt.wantBody(id); rec.Body.String() != want {
+				t.Errorf("body = %q, want %q", rec.Body.String(), want)
+			}
+		})
+	}
+}
+
+func TestRecoverPanicLogsAndCallsBack(t *testing.T) {
+	t.Parallel()
+
+	var reports []middleware.PanicReport
+
+	h := middleware.NewRecoverPanic(
+		middleware.WithPanicCallback(func(_ context.Context, report middleware.PanicReport) {
+			reports = append(reports, report)
+		}),
+	)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic(errBoom) }))
+
+	h.ServeHTTP(httptest.NewRecorder(), newRequest(t, http.MethodGet, "/", nil))
+
+	entries := logged(t, "middleware recovering from panic error")
+	if len(entries) != 1 {
+		t.Fatal("expected one entry, got:", entries)
+	}
+
+	e := entries[0]
+	if e.Level != logger.LogLevels.ERROR || !errors.Is(e.Error, middleware.ErrRecover) || !errors.Is(e.Error, errBoom) ||
+		!loggertest.HasFields(e, logger.Bool("response_committed", false)) {
+		t.Error("unexpected entry:", e)
+	}
+
+	if stack, _ := e.Fields["stack"].(string); !strings.Contains(stack, "recoverPanic_test.go") {
+		t.Error("expected the stack of the panic, got:", stack)
+	}
+
+	if len(reports) != 1 {
+		t.Fatal("expected one report, got:", reports)
+	}
+
+	r := reports[0]
+	if r.Value != errBoom || !errors.Is(r.Err, errBoom) || r.TxID != txID(t) || r.Committed || len(r.Stack) == 0 {
+		t.Errorf("unexpected report: %+v", r)
+	}
+}
+
+func TestRecoverPanicAbortHandler(t *testing.T) {
+	t.Parallel()
+
+	h := middleware.RecoverPanic(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	rec := &statusRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+	if v := panicOf(func() { h.ServeHTTP(rec, newRequest(t, http.MethodGet, "/", nil)) }); v != http.ErrAbortHandler {
+		t.Error("expected http.ErrAbortHandler to be passed on, got:", v)
+	}
+
+	if len(rec.statuses) != 0 || len(logged(t, "")) != 0 {
+		t.Errorf("expected nothing written or logged, got statuses %v", rec.statuses)
+	}
+}
+
+func TestRecoverPanicCommittedResponse(t *testing.T) {
+	t.Parallel()
+
+	var reports []middleware.PanicReport
+
+	h := middleware.NewRecoverPanic(
+		middleware.WithPanicCallback(func(_ context.Context, report middleware.PanicReport) {
+			reports = append(reports, report)
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+
+		panic("boom")
+	}))
+
+	rec := &statusRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+	if v := panicOf(func() { h.ServeHTTP(rec, newRequest(t, http.MethodGet, "/", nil)) }); v != http.ErrAbortHandler {
+		t.Error("expected the response to be aborted, got:", v)
+	}
+
+	if len(rec.statuses) != 1 || rec.statuses[0] != http.StatusAccepted || rec.Body.String() != "partial" {
+		t.Errorf("expected only the response of the handler, got statuses %v and body %q", rec.statuses, rec.Body)
+	}
+
+	entries := logged(t, "middleware recovering from panic error")
+	if len(entries) != 1 || !loggertest.HasFields(entries[0], logger.Bool("response_committed", true)) {
+		t.Error("expected the panic to be logged as committed, got:", entries)
+	}
+
+	if len(reports) != 1 || !reports[0].Committed {
+		t.Error("expected a committed report, got:", reports)
+	}
+}
@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResponseWriter wraps an http.ResponseWriter to record the status, the bytes written and the timings of the response
+// while passing every call through. It implements http.Flusher, http.Hijacker, http.Pusher and io.ReaderFrom by
+// forwarding them to the wrapped writer, returning http.ErrNotSupported, or ErrHijackNotSupported for Hijack, when
+// the wrapped writer cannot do it. Unwrap makes it work with http.NewResponseController.
+type ResponseWriter struct {
+	http.ResponseWriter
+	observers    []func([]byte)
+	start        time.Time
+	firstWriteAt time.Time
+	bytes        int64
+	status       int
+	written      bool
+	hijacked     bool
+}
+
+// NewResponseWriter wraps w. A w that already is a ResponseWriter is returned as it is, so that middlewares in the
+// same chain share it.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+
+	return &ResponseWriter{ResponseWriter: w, start: time.Now()}
+}
+
+// OnWrite calls observe with the bytes of every successful Write, after they are written. Bytes sent with ReadFrom
+// are passed as well, which makes ReadFrom go through Write instead of the wrapped writer.
+func (w *ResponseWriter) OnWrite(observe func(b []byte)) {
+	w.observers = append(w.observers, observe)
+}
+
+// Status returns the status sent to the client, 0 if none is sent yet. Informational statuses are not recorded. A
+// handler that returns without writing gets a 200 from net/http after it returned, which Status cannot see.
+func (w *ResponseWriter) Status() int {
+	return w.status
+}
+
+// statusAfter returns the status of the response once the handler returned, if it did: Status, or the implicit 200 of
+// net/http when nothing was written and the connection was not hijacked.
+func (w *ResponseWriter) statusAfter(returned bool) int {
+	if returned && w.status == 0 && !w.hijacked {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+// Written reports whether the status line of the response is sent, which means that it can no longer change.
+func (w *ResponseWriter) Written() bool {
+	return w.written || w.hijacked
+}
+
+// Hijacked reports whether the connection was taken over with Hijack.
+func (w *ResponseWriter) Hijacked() bool {
+	return w.hijacked
+}
+
+// BytesWritten returns the number of bytes of the body written so far.
+func (w *ResponseWriter) BytesWritten() int64 {
+	return w.bytes
+}
+
+// Elapsed returns the time passed since the ResponseWriter was created.
+func (w *ResponseWriter) Elapsed() time.Duration {
+	return time.Since(w.start)
+}
+
+// TimeToFirstByte returns the time from the creation of the ResponseWriter to the first byte of the body, 0 if no
+// byte is written yet.
+func (w *ResponseWriter) TimeToFirstByte() time.Duration {
+	if w.firstWriteAt.IsZero() {
+		return 0
+	}
+
+	return w.firstWriteAt.Sub(w.start)
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteHeader records the first final status and forwards every call.
+func (w *ResponseWriter) WriteHeader(statusCode int) {
+	if !w.written && (statusCode >= http.StatusOK || statusCode == http.StatusSwitchingProtocols) {
+		w.status = statusCode
+		w.written = true
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write writes b and records it.
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	w.writeImplicitHeader()
+
+	n, err := w.ResponseWriter.Write(b)
+	w.record(b[:n])
+
+	return n, err
+}
+
+// ReadFrom forwards to the first io.ReaderFrom in the Unwrap chain of the wrapped writer, if any, to keep
+// optimizations like sendfile. It goes through Write when the bytes are observed or no wrapped writer can read from r.
+func (w *ResponseWriter) ReadFrom(r io.Reader) (int64, error) {
+	rf, ok := unwrapTo[io.ReaderFrom](w.ResponseWriter)
+	if !ok || len(w.observers) > 0 {
+		// writerOnly hides ReadFrom from io.Copy, which would call it again.
+		return io.Copy(writerOnly{w}, r)
+	}
+
+	w.writeImplicitHeader()
+
+	n, err := rf.ReadFrom(r)
+	if n > 0 {
+		if w.firstWriteAt.IsZero() {
+			w.firstWriteAt = time.Now()
+		}
+
+		w.bytes += n
+	}
+
+	return n, err
+}
+
+// Flush sends the buffered data to the client, if the wrapped writer can.
+func (w *ResponseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError works like Flush but returns the error, which http.ResponseController prefers.
+func (w *ResponseWriter) FlushError() error {
+	w.writeImplicitHeader()
+
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack takes over the connection, if the wrapped writer can.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w : %v", ErrHijackNotSupported, err)
+	}
+
+	w.hijacked = true
+
+	return conn, rw, nil
+}
+
+// Push starts an HTTP/2 server push, if the wrapped writer can.
+func (w *ResponseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := unwrapTo[http.Pusher](w.ResponseWriter); ok {
+		return p.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
+
+func (w *ResponseWriter) writeImplicitHeader() {
+	if !w.written {
+		w.status = http.StatusOK
+		w.written = true
+	}
+}
+
+func (w *ResponseWriter) record(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+
+	if w.firstWriteAt.IsZero() {
+		w.firstWriteAt = time.Now()
+	}
+
+	w.bytes += int64(len(b))
+
+	for _, observe := range w.observers {
+		observe(b)
+	}
+}
+
+type writerOnly struct {
+	io.Writer
+}
+
+// unwrapTo returns the first writer in the Unwrap chain of w that implements T.
+func unwrapTo[T any](w http.ResponseWriter) (T, bool) {
+	for w != nil {
+		if t, ok := w.(T); ok {
+			return t, true
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+
+		w = u.Unwrap()
+	}
+
+	var zero T
+
+	return zero, false
+}
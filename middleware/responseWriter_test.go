@@ -0,0 +1,211 @@
+package middleware_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mikarios/golib/middleware"
+)
+
+// unwrapper hides the methods of the writer it wraps but Unwrap, like the writers of other middlewares.
+type unwrapper struct {
+	http.ResponseWriter
+}
+
+func (u unwrapper) Unwrap() http.ResponseWriter {
+	return u.ResponseWriter
+}
+
+// readerFrom counts the bytes it reads with ReadFrom.
+type readerFrom struct {
+	*httptest.ResponseRecorder
+	read int64
+}
+
+func (rf *readerFrom) ReadFrom(r io.Reader) (int64, error) {
+	n, err := io.Copy(rf.ResponseRecorder, r)
+	rf.read += n
+
+	return n, err
+}
+
+func TestResponseWriterFlush(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	rw := middleware.NewResponseWriter(unwrapper{rec})
+
+	if err := http.NewResponseController(rw).Flush(); err != nil {
+		t.Fatal("flush failed:", err)
+	}
+
+	if !rec.Flushed || rw.Status() != http.StatusOK || !rw.Written() {
+		t.Errorf("expected a flushed 200, got flushed %v and status %d", rec.Flushed, rw.Status())
+	}
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	t.Parallel()
+
+	hijacked := make(chan bool, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := middleware.NewResponseWriter(w)
+
+		conn, buf, err := http.NewResponseController(rw).Hijack()
+		if err != nil {
+			t.Error("hijack failed:", err)
+			hijacked <- false
+
+			return
+		}
+		defer conn.Close()
+
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		_ = buf.Flush()
+		hijacked <- rw.Hijacked() && rw.Written()
+	}))
+	defer srv.Close()
+
+	res := get(t, srv.URL)
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if string(body) != "hijacked" || !<-hijacked {
+		t.Errorf("expected the hijacked answer, got %q", body)
+	}
+}
+
+func TestResponseWriterReadFrom(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		observe  bool
+		wantRead int64
+	}{
+		{name: "forwarded without observers", wantRead: 5},
+		{name: "written with observers", observe: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rf := &readerFrom{ResponseRecorder: httptest.NewRecorder()}
+			rw := middleware.NewResponseWriter(unwrapper{rf})
+
+			var observed strings.Builder
+			if tt.observe {
+				rw.OnWrite(func(b []byte) { observed.Write(b) })
+			}
+
+			// The reader hides WriteTo, which io.Copy would prefer over ReadFrom.
+			n, err := io.Copy(rw, struct{ io.Reader }{strings.NewReader("hello")})
+			if err != nil || n != 5 {
+				t.Fatalf("copied %d bytes with error %v", n, err)
+			}
+
+			if rf.read != tt.wantRead || rw.BytesWritten() != 5 || rf.Body.String() != "hello" {
+				t.Errorf("read %d with ReadFrom, want %d, recorded %d bytes", rf.read, tt.wantRead, rw.BytesWritten())
+			}
+
+			if tt.observe && observed.String() != "hello" {
+				t.Errorf("observed %q", observed.String())
+			}
+		})
+	}
+}
+
+func TestResponseWriterPushHTTP1(t *testing.T) {
+	t.Parallel()
+
+	pushErr := make(chan error, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushErr <- middleware.NewResponseWriter(w).Push("/style.css", nil)
+	}))
+	defer srv.Close()
+
+	res := get(t, srv.URL)
+	defer res.Body.Close()
+
+	if err := <-pushErr; !errors.Is(err, http.ErrNotSupported) {
+		t.Error("expected http.ErrNotSupported, got:", err)
+	}
+}
+
+func TestResponseWriterRecordsResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		handler    func(w http.ResponseWriter)
+		wantStatus int
+		wantBytes  int64
+	}{
+		{
+			name: "explicit status",
+			handler: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("created"))
+				_, _ = w.Write([]byte(" twice"))
+			},
+			wantStatus: http.StatusCreated,
+			wantBytes:  13,
+		},
+		{
+			name: "implicit status",
+			handler: func(w http.ResponseWriter) {
+				_, _ = io.Copy(w, strings.NewReader("copied"))
+			},
+			wantStatus: http.StatusOK,
+			wantBytes:  6,
+		},
+		{
+			name: "informational status first",
+			handler: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusEarlyHints)
+				w.WriteHeader(http.StatusAccepted)
+			},
+			wantStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorded := make(chan *middleware.ResponseWriter, 1)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rw := middleware.NewResponseWriter(w)
+				tt.handler(rw)
+				recorded <- rw
+			}))
+			defer srv.Close()
+
+			res := get(t, srv.URL)
+			defer res.Body.Close()
+
+			body, _ := io.ReadAll(res.Body)
+			rw := <-recorded
+
+			if rw.Status() != res.StatusCode || rw.Status() != tt.wantStatus {
+				t.Errorf("status = %d, client got %d, want %d", rw.Status(), res.StatusCode, tt.wantStatus)
+			}
+
+			if rw.BytesWritten() != int64(len(body)) || rw.BytesWritten() != tt.wantBytes {
+				t.Errorf("bytes = %d, client got %d, want %d", rw.BytesWritten(), len(body), tt.wantBytes)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mikarios/golib/logger"
+)
+
+// DefaultClientTimeoutHeader is a header clients can use to ask for a shorter timeout, see WithClientTimeout.
+const DefaultClientTimeoutHeader = "Request-Timeout"
+
+// TimeoutOption configures the middleware created by NewTimeout.
+type TimeoutOption func(*timeoutConfig)
+
+type routeTimeout struct {
+	timeout  time.Duration
+	matchers []RequestMatcher
+}
+
+type timeoutConfig struct {
+	timeout      time.Duration
+	routes       []routeTimeout
+	status       int
+	contentType  string
+	body         []byte
+	clientHeader string
+}
+
+// WithTimeoutResponse sets the response sent when the timeout expires, by default a 503 with the status text. A 504
+// suits services whose time is mostly spent waiting for others.
+func WithTimeoutResponse(status int, contentType string, body []byte) TimeoutOption {
+	return func(cfg *timeoutConfig) {
+		cfg.status = status
+		cfg.contentType = contentType
+		cfg.body = body
+	}
+}
+
+// WithRouteTimeout uses timeout for the requests matched by one of matchers. The first matching override wins and a
+// timeout <= 0 means no timeout, e.g. for streaming routes.
+func WithRouteTimeout(timeout time.Duration, matchers ...RequestMatcher) TimeoutOption {
+	return func(cfg *timeoutConfig) {
+		cfg.routes = append(cfg.routes, routeTimeout{timeout: timeout, matchers: matchers})
+	}
+}
+
+// WithClientTimeout lets clients ask for a shorter timeout in header, DefaultClientTimeoutHeader if empty, as a
+// duration like "1.5s" or a number of seconds. Longer timeouts than the configured one are ignored, so that clients
+// cannot hold on to the server longer.
+func WithClientTimeout(header string) TimeoutOption {
+	return func(cfg *timeoutConfig) {
+		if header == "" {
+			header = DefaultClientTimeoutHeader
+		}
+
+		cfg.clientHeader = header
+	}
+}
+
+// Timeout is a middleware that bounds the handlers to timeout. It works like NewTimeout without options.
+func Timeout(timeout time.Duration) func(next http.Handler) http.Handler {
+	return NewTimeout(timeout)
+}
+
+// NewTimeout creates a middleware that cancels the context of the request after timeout and, if the handler has not
+// returned by then, logs a warning with the transaction id and sends the timeout response. Writes of the abandoned
+// handler fail with http.ErrHandlerTimeout from then on. Like http.TimeoutHandler, the response is buffered until the
+// handler returns, so streaming handlers and ones hijacking the connection should be given no timeout with
+// WithRouteTimeout. Panics of the handler are passed on to the middlewares around it with the stack of the handler,
+// which RecoverPanic logs. Panics after the timeout are logged with ErrRecover.
+func NewTimeout(timeout time.Duration, opts ...TimeoutOption) func(next http.Handler) http.Handler {
+	cfg := &timeoutConfig{timeout: timeout, status: http.StatusServiceUnavailable}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.body == nil {
+		cfg.contentType = "text/plain; charset=utf-8"
+		cfg.body = []byte(http.StatusText(cfg.status))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := cfg.timeoutOf(r)
+			if d <= 0 {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			cfg.serve(next, w, r, d)
+		})
+	}
+}
+
+func (cfg *timeoutConfig) serve(next http.Handler, w http.ResponseWriter, r *http.Request, d time.Duration) {
+	ctx, cancel := context.WithTimeout(r.Context(), d)
+	defer cancel()
+
+	r = r.WithContext(ctx)
+	tw := &timeoutWriter{header: http.Header{}, status: http.StatusOK}
+	done := make(chan struct{})
+	panicked := make(chan interface{}, 1)
+
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				panicked <- handlerPanicOf(v)
+			}
+		}()
+
+		next.ServeHTTP(tw, r)
+		close(done)
+	}()
+
+	select {
+	case v := <-panicked:
+		panic(v)
+	case <-done:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+
+		dst := w.Header()
+		for k, v := range tw.header {
+			dst[k] = v
+		}
+
+		w.WriteHeader(tw.status)
+		_, _ = w.Write(tw.buf.Bytes())
+	case <-ctx.Done():
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+
+		tw.timedOut = true
+
+		go logLatePanic(ctx, r, done, panicked)
+
+		// The client went away, nobody is left to answer.
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+
+		logger.Warning(
+			ctx,
+			"request timed out",
+			logger.Duration("timeout", d),
+			logger.String("method", r.Method),
+			logger.String("path", r.URL.Path),
+		)
+
+		w.Header().Set("Content-Type", cfg.contentType)
+		w.WriteHeader(cfg.status)
+		_, _ = w.Write(cfg.body)
+	}
+}
+
+// logLatePanic waits for the abandoned handler of r to return and logs its panic, if it panics, since nobody is left
+// to recover it.
+func logLatePanic(ctx context.Context, r *http.Request, done <-chan struct{}, panicked <-chan interface{}) {
+	var v interface{}
+
+	select {
+	case <-done:
+		return
+	case v = <-panicked:
+	}
+
+	p, ok := v.(handlerPanic)
+	if !ok {
+		// http.ErrAbortHandler aborts on purpose.
+		return
+	}
+
+	logger.Error(
+		ctx,
+		panicError(p.value),
+		"handler panicked after the timeout",
+		logger.String("stack", string(p.stack)),
+		logger.String("method", r.Method),
+		logger.String("path", r.URL.Path),
+	)
+}
+
+// handlerPanic is the value Timeout panics with when the handler panicked on its own goroutine, so that the stack of
+// the handler is not lost.
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// String shows the stack of the handler when net/http logs the panic.
+func (p handlerPanic) String() string {
+	return fmt.Sprintf("%v\n\nstack of the handler:\n%s", p.value, p.stack)
+}
+
+// handlerPanicOf returns the value to panic with for v, recovered on the goroutine of the handler. http.ErrAbortHandler
+// is passed on as it is, since net/http and RecoverPanic look for it, and so are the panics of nested Timeouts.
+func handlerPanicOf(v interface{}) interface{} {
+	if _, ok := v.(handlerPanic); ok || v == http.ErrAbortHandler { // nolint:errorlint,goerr113 // see RecoverPanic.
+		return v
+	}
+
+	return handlerPanic{value: v, stack: debug.Stack()}
+}
+
+// timeoutOf returns the timeout of r: the one of the first matching route override or the default one, shortened by
+// the client if allowed.
+func (cfg *timeoutConfig) timeoutOf(r *http.Request) time.Duration {
+	d := cfg.timeout
+
+	for _, route := range cfg.routes {
+		if matchAny(r, route.matchers) {
+			d = route.timeout
+
+			break
+		}
+	}
+
+	if d <= 0 || cfg.clientHeader == "" {
+		return d
+	}
+
+	if client, ok := parseClientTimeout(r.Header.Get(cfg.clientHeader)); ok && client < d {
+		return client
+	}
+
+	return d
+}
+
+func parseClientTimeout(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, true
+	}
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// timeoutWriter buffers the response of the handler until it returns, and refuses writes once the timeout expired.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	tw.wroteHeader = true
+
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(statusCode int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+
+	tw.wroteHeader = true
+	tw.status = statusCode
+}
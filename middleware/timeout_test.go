@@ -0,0 +1,324 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/middleware"
+)
+
+func TestTimeoutInTime(t *testing.T) {
+	t.Parallel()
+
+	h := middleware.Timeout(time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Handler", "yes")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("done"))
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rec := &statusRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(rec, newRequest(t, http.MethodGet, "/", nil))
+
+	if len(rec.statuses) != 1 || rec.Code != http.StatusCreated || rec.Header().Get("X-Handler") != "yes" ||
+		rec.Body.String() != "done" {
+		t.Errorf("expected the response of the handler, got %v %v %q", rec.statuses, rec.Header(), rec.Body)
+	}
+}
+
+func TestTimeoutExceeded(t *testing.T) {
+	t.Parallel()
+
+	served := make(chan struct{})
+	lateWrite := make(chan error, 1)
+
+	h := middleware.Timeout(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		// Write once the timeout response is sent, since returning earlier could beat the timeout.
+		<-served
+
+		_, err := w.Write([]byte("too late"))
+		lateWrite <- err
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(t, http.MethodGet, "/slow", nil))
+	close(served)
+
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "Service Unavailable" ||
+		rec.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("expected the timeout response, got %d %q", rec.Code, rec.Body)
+	}
+
+	if err := <-lateWrite; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Error("expected http.ErrHandlerTimeout, got:", err)
+	}
+
+	entries := logged(t, "request timed out")
+	if len(entries) != 1 || entries[0].Level != logger.LogLevels.WARNING || entries[0].TxID != txID(t) {
+		t.Error("expected a warning with the transaction id, got:", entries)
+	}
+}
+
+func TestTimeoutResponse(t *testing.T) {
+	t.Parallel()
+
+	h := middleware.NewTimeout(
+		time.Millisecond,
+		middleware.WithTimeoutResponse(http.StatusGatewayTimeout, "application/json", []byte(`{"error":"timeout"}`)),
+	)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(t, http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusGatewayTimeout || rec.Body.String() != `{"error":"timeout"}` ||
+		rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected the configured response, got %d %q", rec.Code, rec.Body)
+	}
+}
+
+func TestTimeoutOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		opts         []middleware.TimeoutOption
+		target       string
+		header       string
+		wantDeadline bool
+		wantMin      time.Duration
+		wantMax      time.Duration
+	}{
+		{name: "configured timeout", target: "/", wantDeadline: true, wantMin: time.Minute, wantMax: time.Hour},
+		{
+			name:    "route without timeout",
+			opts:    []middleware.TimeoutOption{middleware.WithRouteTimeout(0, middleware.MatchPath("/stream"))},
+			target:  "/stream",
+			header:  "2s",
+			wantMax: time.Hour,
+		},
+		{
+			name:         "other route",
+			opts:         []middleware.TimeoutOption{middleware.WithRouteTimeout(-1, middleware.MatchPath("/stream"))},
+			target:       "/orders",
+			wantDeadline: true,
+			wantMin:      time.Minute,
+			wantMax:      time.Hour,
+		},
+		{
+			name: "first matching route",
+			opts: []middleware.TimeoutOption{
+				middleware.WithRouteTimeout(time.Minute, middleware.MatchPath("/reports/*")),
+				middleware.WithRouteTimeout(0, middleware.MatchPath("/reports/*")),
+			},
+			target:       "/reports/daily",
+			wantDeadline: true,
+			wantMin:      time.Second,
+			wantMax:      time.Minute,
+		},
+		{
+			name:         "shorter client timeout",
+			opts:         []middleware.TimeoutOption{middleware.WithClientTimeout("")},
+			target:       "/",
+			header:       "2s",
+			wantDeadline: true,
+			wantMin:      time.Second,
+			wantMax:      2 * time.Second,
+		},
+		{
+			name:         "client timeout in seconds",
+			opts:         []middleware.TimeoutOption{middleware.WithClientTimeout("")},
+			target:       "/",
+			header:       "1.5",
+			wantDeadline: true,
+			wantMin:      time.Second,
+			wantMax:      1500 * time.Millisecond,
+		},
+		{
+			name:         "longer client timeout",
+			opts:         []middleware.TimeoutOption{middleware.WithClientTimeout("")},
+			target:       "/",
+			header:       "2h",
+			wantDeadline: true,
+			wantMin:      time.Minute,
+			wantMax:      time.Hour,
+		},
+		{
+			name:         "invalid client timeout",
+			opts:         []middleware.TimeoutOption{middleware.WithClientTimeout("")},
+			target:       "/",
+			header:       "-2s",
+			wantDeadline: true,
+			wantMin:      time.Minute,
+			wantMax:      time.Hour,
+		},
+		{
+			name:         "client timeout not allowed",
+			target:       "/",
+			header:       "2s",
+			wantDeadline: true,
+			wantMin:      time.Minute,
+			wantMax:      time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				deadline time.Time
+				ok       bool
+			)
+
+			h := middleware.NewTimeout(time.Hour, tt.opts...)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				deadline, ok = r.Context().Deadline()
+			}))
+
+			req := newRequest(t, http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set(middleware.DefaultClientTimeoutHeader, tt.header)
+			}
+
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if ok != tt.wantDeadline {
+				t.Fatalf("deadline = %v, want %v", ok, tt.wantDeadline)
+			}
+
+			if left := time.Until(deadline); ok && (left < tt.wantMin || left > tt.wantMax) {
+				t.Errorf("timeout = %v, want between %v and %v", left, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestTimeoutClientCancel(t *testing.T) {
+	t.Parallel()
+
+	served := make(chan struct{})
+	started := make(chan struct{})
+
+	h := middleware.Timeout(time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		<-served
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	req := newRequest(t, http.MethodGet, "/", nil)
+	rec := &statusRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(rec, req.WithContext(context.WithValue(ctx, logger.Settings.TransactionKey, txID(t))))
+	close(served)
+
+	if len(rec.statuses) != 0 || rec.Body.Len() != 0 || len(logged(t, "")) != 0 {
+		t.Errorf("expected nothing written or logged, got %v %q", rec.statuses, rec.Body)
+	}
+}
+
+// panicHere panics with "boom", to be found in the stack of the panic.
+func panicHere() {
+	panic("boom")
+}
+
+func TestTimeoutPanicStack(t *testing.T) {
+	t.Parallel()
+
+	var reports []middleware.PanicReport
+
+	h := middleware.NewRecoverPanic(
+		middleware.WithPanicCallback(func(_ context.Context, report middleware.PanicReport) {
+			reports = append(reports, report)
+		}),
+	)(middleware.Timeout(time.Hour)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panicHere()
+	})))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(t, http.MethodGet, "/", nil))
+
+	if len(reports) != 1 {
+		t.Fatal("expected one report, got:", len(reports))
+	}
+
+	if r := reports[0]; r.Value != "boom" || !strings.Contains(string(r.Stack), "panicHere") {
+		t.Errorf("expected the value and the stack of the handler, got %v and:\n%s", r.Value, r.Stack)
+	}
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Error("expected a 500, got:", rec.Code)
+	}
+
+	entries := logged(t, "middleware recovering from panic error")
+	if len(entries) != 1 {
+		t.Fatal("expected one entry, got:", entries)
+	}
+
+	if stack, _ := entries[0].Fields["stack"].(string); !strings.Contains(stack, "panicHere") {
+		t.Error("expected the stack of the handler to be logged, got:", stack)
+	}
+}
+
+func TestTimeoutLatePanic(t *testing.T) {
+	t.Parallel()
+
+	served := make(chan struct{})
+
+	h := middleware.Timeout(time.Millisecond)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		<-served
+
+		panicHere()
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), newRequest(t, http.MethodGet, "/", nil))
+	close(served)
+
+	var entries []logger.Entry
+
+	for deadline := time.Now().Add(5 * time.Second); len(entries) == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+
+		entries = logged(t, "handler panicked after the timeout")
+	}
+
+	if len(entries) != 1 || entries[0].Level != logger.LogLevels.ERROR ||
+		!errors.Is(entries[0].Error, middleware.ErrRecover) {
+		t.Fatal("expected the late panic to be logged with ErrRecover, got:", entries)
+	}
+
+	if stack, _ := entries[0].Fields["stack"].(string); !strings.Contains(stack, "panicHere") {
+		t.Error("expected the stack of the handler, got:", stack)
+	}
+}
+
+func TestTimeoutAbortHandler(t *testing.T) {
+	t.Parallel()
+
+	h := middleware.Timeout(time.Hour)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	req := newRequest(t, http.MethodGet, "/", nil)
+
+	if v := panicOf(func() { h.ServeHTTP(httptest.NewRecorder(), req) }); v != http.ErrAbortHandler {
+		t.Error("expected http.ErrAbortHandler to be passed on, got:", v)
+	}
+}
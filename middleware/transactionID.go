@@ -3,25 +3,266 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/mikarios/golib/hid"
 	"github.com/mikarios/golib/logger"
 )
 
-// TransactionID is operating as middleware to set a transaction ID in request header and context. If a header with
-// a transaction ID does not exist, generates a new one.
+// TraceparentHeader is the W3C trace context header carrying the trace id of the caller.
+const TraceparentHeader = "traceparent"
+
+// DefaultMaxTransactionIDLength is the longest incoming transaction id accepted unless told otherwise.
+const DefaultMaxTransactionIDLength = 128
+
+// IDGenerator creates transaction ids.
+type IDGenerator func() string
+
+// TransactionIDOption configures the middleware created by NewTransactionID.
+type TransactionIDOption func(*txConfig)
+
+type txConfig struct {
+	generator   IDGenerator
+	validator   func(id string) bool
+	maxLength   int
+	inbound     []string
+	outbound    string
+	echo        bool
+	traceparent bool
+}
+
+type txHeaderKey struct{}
+
+// UUIDv4 generates random uuids, the default of NewTransactionID.
+func UUIDv4() string {
+	return uuid.NewString()
+}
+
+// UUIDv7 generates time ordered uuids, which index better than random ones.
+func UUIDv7() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+
+	return id.String()
+}
+
+// ULID generates time ordered ids of 26 characters.
+func ULID() string {
+	return ulid.Make().String()
+}
+
+// HID returns a generator of human readable ids with hid.New.
+func HID(includeCaps bool, splitEvery int, separator *rune) IDGenerator {
+	return func() string {
+		return hid.New(includeCaps, splitEvery, separator)
+	}
+}
+
+// ValidTransactionID accepts ids made of letters, digits and "-", "_", ".", ":", which covers the ids of every
+// generator of the package and keeps anything that could mislead a log reader out.
+func ValidTransactionID(id string) bool {
+	if id == "" {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// WithGenerator generates new ids with generator instead of UUIDv4.
+func WithGenerator(generator IDGenerator) TransactionIDOption {
+	return func(cfg *txConfig) {
+		cfg.generator = generator
+	}
+}
+
+// WithValidator accepts the incoming ids for which validator returns true, instead of ValidTransactionID. Rejected
+// ids are replaced with new ones.
+func WithValidator(validator func(id string) bool) TransactionIDOption {
+	return func(cfg *txConfig) {
+		cfg.validator = validator
+	}
+}
+
+// WithMaxLength rejects incoming ids longer than maxLength, DefaultMaxTransactionIDLength by default.
+func WithMaxLength(maxLength int) TransactionIDOption {
+	return func(cfg *txConfig) {
+		cfg.maxLength = maxLength
+	}
+}
+
+// WithInboundHeaders reads the incoming id from the first of the headers that has a valid one, instead of the header
+// named after logger.Settings.TransactionKey.
+func WithInboundHeaders(names ...string) TransactionIDOption {
+	return func(cfg *txConfig) {
+		cfg.inbound = names
+	}
+}
+
+// WithOutboundHeader names the header that carries the id on the response and on outgoing calls, the first inbound
+// header by default.
+func WithOutboundHeader(name string) TransactionIDOption {
+	return func(cfg *txConfig) {
+		cfg.outbound = name
+	}
+}
+
+// WithEcho decides whether the id is sent back on the response, which it is by default.
+func WithEcho(echo bool) TransactionIDOption {
+	return func(cfg *txConfig) {
+		cfg.echo = echo
+	}
+}
+
+// WithTraceparent decides whether requests without an id take the trace id of their OpenTelemetry span or traceparent
+// header, which they do by default.
+func WithTraceparent(use bool) TransactionIDOption {
+	return func(cfg *txConfig) {
+		cfg.traceparent = use
+	}
+}
+
+// TransactionID is operating as middleware to set a transaction ID in the context. It works like NewTransactionID
+// without options.
 func TransactionID(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		txKey := string(logger.Settings.TransactionKey)
+	return NewTransactionID()(next)
+}
+
+// NewTransactionID creates a middleware that sets a transaction id in the context of the request, under
+// logger.Settings.TransactionKey. The id of the inbound header is used if it is valid. Otherwise the trace id of the
+// request is used, taken from the OpenTelemetry span in the context or the W3C traceparent header, so that logs and
+// traces share the same id, and a new one is generated for requests that are not part of a trace. The request is not
+// modified, the id is echoed on the response and PropagateTransactionID adds it to outgoing calls.
+func NewTransactionID(opts ...TransactionIDOption) func(next http.Handler) http.Handler {
+	cfg := &txConfig{
+		generator:   UUIDv4,
+		validator:   ValidTransactionID,
+		maxLength:   DefaultMaxTransactionIDLength,
+		inbound:     []string{string(logger.Settings.TransactionKey)},
+		echo:        true,
+		traceparent: true,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.outbound == "" && len(cfg.inbound) > 0 {
+		cfg.outbound = cfg.inbound[0]
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			txID := cfg.transactionID(r)
+
+			if cfg.echo && cfg.outbound != "" {
+				w.Header().Set(cfg.outbound, txID)
+			}
+
+			ctx := context.WithValue(r.Context(), logger.Settings.TransactionKey, txID)
+			ctx = context.WithValue(ctx, txHeaderKey{}, cfg.outbound)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// PropagateTransactionID sets the transaction id of the context of req on req, in the outbound header of the
+// NewTransactionID that created it, or in the header named after logger.Settings.TransactionKey. Headers set already
+// are left alone.
+func PropagateTransactionID(req *http.Request) {
+	txID, _ := req.Context().Value(logger.Settings.TransactionKey).(string)
+	if txID == "" {
+		return
+	}
+
+	header, _ := req.Context().Value(txHeaderKey{}).(string)
+	if header == "" {
+		header = string(logger.Settings.TransactionKey)
+	}
+
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	if req.Header.Get(header) == "" {
+		req.Header.Set(header, txID)
+	}
+}
+
+func (cfg *txConfig) transactionID(r *http.Request) string {
+	// An outer TransactionID already chose the id, e.g. when chains are nested.
+	if _, ok := r.Context().Value(txHeaderKey{}).(string); ok {
+		if id, _ := r.Context().Value(logger.Settings.TransactionKey).(string); id != "" {
+			return id
+		}
+	}
+
+	for _, name := range cfg.inbound {
+		if id := r.Header.Get(name); cfg.valid(id) {
+			return id
+		}
+	}
 
-		txID := r.Header.Get(txKey)
-		if txID == "" {
-			txID = uuid.New().String()
-			r.Header.Add(txKey, txID)
+	if cfg.traceparent {
+		if id, ok := traceID(r); ok {
+			return id
 		}
+	}
+
+	return cfg.generator()
+}
+
+func (cfg *txConfig) valid(id string) bool {
+	return id != "" && (cfg.maxLength <= 0 || len(id) <= cfg.maxLength) && cfg.validator(id)
+}
+
+// traceID returns the trace id of r, if r is part of a trace.
+func traceID(r *http.Request) (string, bool) {
+	if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+		return sc.TraceID().String(), true
+	}
+
+	return traceIDFromTraceparent(r.Header.Get(TraceparentHeader))
+}
+
+// traceIDFromTraceparent parses a traceparent header of the form version-traceid-parentid-flags and returns its
+// trace id. Version ff and all zero ids are invalid according to the specification.
+func traceIDFromTraceparent(header string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || !isHexByte(parts[0]) || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", false
+	}
+
+	id, err := trace.TraceIDFromHex(parts[1])
+	if err != nil {
+		return "", false
+	}
+
+	if _, err = trace.SpanIDFromHex(parts[2]); err != nil {
+		return "", false
+	}
+
+	if !isHexByte(parts[3]) {
+		return "", false
+	}
+
+	return id.String(), true
+}
 
-		ctx := context.WithValue(r.Context(), logger.Settings.TransactionKey, txID)
-		next.ServeHTTP(rw, r.WithContext(ctx))
-	})
+func isHexByte(s string) bool {
+	return len(s) == 2 && strings.Trim(s, "0123456789abcdef") == ""
 }
@@ -0,0 +1,297 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/middleware"
+)
+
+const (
+	traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	traceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+)
+
+func generated() string {
+	return "generated"
+}
+
+// serveTransactionID serves req with the middlewares and returns the transaction id of the context of the handler
+// and the response.
+func serveTransactionID(
+	req *http.Request,
+	mws ...func(http.Handler) http.Handler,
+) (string, *httptest.ResponseRecorder) {
+	var id string
+
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ = r.Context().Value(logger.Settings.TransactionKey).(string)
+	})
+
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return id, rec
+}
+
+func TestNewTransactionID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opts    []middleware.TransactionIDOption
+		headers map[string]string
+		ctx     func(context.Context) context.Context
+		want    string
+	}{
+		{name: "new id", want: "generated"},
+		{name: "inbound id", headers: map[string]string{"txID": "abc-123"}, want: "abc-123"},
+		{name: "invalid id", headers: map[string]string{"txID": "abc\n[ERROR] forged"}, want: "generated"},
+		{name: "too long id", headers: map[string]string{"txID": strings.Repeat("a", 129)}, want: "generated"},
+		{
+			name:    "longest id",
+			headers: map[string]string{"txID": strings.Repeat("a", 128)},
+			want:    strings.Repeat("a", 128),
+		},
+		{
+			name:    "no length limit",
+			opts:    []middleware.TransactionIDOption{middleware.WithMaxLength(0)},
+			headers: map[string]string{"txID": strings.Repeat("a", 200)},
+			want:    strings.Repeat("a", 200),
+		},
+		{
+			name:    "custom validator",
+			opts:    []middleware.TransactionIDOption{middleware.WithValidator(func(id string) bool { return id == "ok" })},
+			headers: map[string]string{"txID": "abc-123"},
+			want:    "generated",
+		},
+		{
+			name:    "first inbound header",
+			opts:    []middleware.TransactionIDOption{middleware.WithInboundHeaders("X-Request-Id", "X-Correlation-Id")},
+			headers: map[string]string{"X-Request-Id": "first", "X-Correlation-Id": "second"},
+			want:    "first",
+		},
+		{
+			name:    "next inbound header when the first is invalid",
+			opts:    []middleware.TransactionIDOption{middleware.WithInboundHeaders("X-Request-Id", "X-Correlation-Id")},
+			headers: map[string]string{"X-Request-Id": "in valid", "X-Correlation-Id": "second"},
+			want:    "second",
+		},
+		{name: "traceparent", headers: map[string]string{"traceparent": traceparent}, want: traceID},
+		{
+			name:    "inbound id wins over traceparent",
+			headers: map[string]string{"txID": "abc-123", "traceparent": traceparent},
+			want:    "abc-123",
+		},
+		{
+			name:    "traceparent disabled",
+			opts:    []middleware.TransactionIDOption{middleware.WithTraceparent(false)},
+			headers: map[string]string{"traceparent": traceparent},
+			want:    "generated",
+		},
+		{
+			name:    "traceparent version ff",
+			headers: map[string]string{"traceparent": "ff" + traceparent[2:]},
+			want:    "generated",
+		},
+		{
+			name:    "traceparent of a later version",
+			headers: map[string]string{"traceparent": "01" + traceparent[2:] + "-later"},
+			want:    traceID,
+		},
+		{
+			name:    "traceparent version 00 with more fields",
+			headers: map[string]string{"traceparent": traceparent + "-later"},
+			want:    "generated",
+		},
+		{
+			name:    "all zero trace id",
+			headers: map[string]string{"traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+			want:    "generated",
+		},
+		{
+			name:    "all zero parent id",
+			headers: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+			want:    "generated",
+		},
+		{
+			name: "span of the context",
+			ctx: func(ctx context.Context) context.Context {
+				tid, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+				sid, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+
+				return trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+					TraceID: tid,
+					SpanID:  sid,
+				}))
+			},
+			headers: map[string]string{"traceparent": traceparent},
+			want:    "0af7651916cd43dd8448eb211c80319c",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			if tt.ctx != nil {
+				req = req.WithContext(tt.ctx(req.Context()))
+			}
+
+			opts := append([]middleware.TransactionIDOption{middleware.WithGenerator(generated)}, tt.opts...)
+
+			if id, _ := serveTransactionID(req, middleware.NewTransactionID(opts...)); id != tt.want {
+				t.Errorf("transaction id = %q, want %q", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionIDEcho(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		opts       []middleware.TransactionIDOption
+		wantHeader string
+		want       string
+	}{
+		{name: "default header", wantHeader: "txID", want: "abc-123"},
+		{
+			name:       "first inbound header",
+			opts:       []middleware.TransactionIDOption{middleware.WithInboundHeaders("X-Request-Id", "txID")},
+			wantHeader: "X-Request-Id",
+			want:       "abc-123",
+		},
+		{
+			name:       "outbound header",
+			opts:       []middleware.TransactionIDOption{middleware.WithOutboundHeader("X-Trace")},
+			wantHeader: "X-Trace",
+			want:       "abc-123",
+		},
+		{
+			name:       "no echo",
+			opts:       []middleware.TransactionIDOption{middleware.WithEcho(false)},
+			wantHeader: "txID",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("txID", "abc-123")
+
+			_, rec := serveTransactionID(req, middleware.NewTransactionID(tt.opts...))
+			if got := rec.Header().Get(tt.wantHeader); got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.wantHeader, got, tt.want)
+			}
+
+			if len(req.Header) != 1 {
+				t.Error("expected the request to be left alone, got:", req.Header)
+			}
+		})
+	}
+}
+
+func TestTransactionIDNested(t *testing.T) {
+	t.Parallel()
+
+	outer := middleware.NewTransactionID(middleware.WithGenerator(func() string { return "outer" }))
+	inner := middleware.NewTransactionID(
+		middleware.WithGenerator(func() string { return "inner" }),
+		middleware.WithInboundHeaders("X-Request-Id"),
+	)
+
+	id, rec := serveTransactionID(httptest.NewRequest(http.MethodGet, "/", nil), outer, inner)
+	if id != "outer" || rec.Header().Get("txID") != "outer" || rec.Header().Get("X-Request-Id") != "outer" {
+		t.Errorf("expected the id of the outer middleware everywhere, got %q and headers %v", id, rec.Header())
+	}
+}
+
+func TestPropagateTransactionID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		mw         func(http.Handler) http.Handler
+		preset     string
+		wantHeader string
+		want       string
+	}{
+		{name: "default header", mw: middleware.TransactionID, wantHeader: "txID", want: "abc-123"},
+		{
+			name:       "outbound header",
+			mw:         middleware.NewTransactionID(middleware.WithOutboundHeader("X-Request-Id")),
+			wantHeader: "X-Request-Id",
+			want:       "abc-123",
+		},
+		{name: "header set already", mw: middleware.TransactionID, preset: "kept", wantHeader: "txID", want: "kept"},
+		{
+			name:       "id of the context only",
+			mw:         func(next http.Handler) http.Handler { return next },
+			wantHeader: "txID",
+			want:       "from-context",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out *http.Request
+
+			h := tt.mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				out, _ = http.NewRequestWithContext(r.Context(), http.MethodGet, "http://upstream/", nil)
+				if tt.preset != "" {
+					out.Header.Set(tt.wantHeader, tt.preset)
+				}
+
+				middleware.PropagateTransactionID(out)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("txID", "abc-123")
+			req = req.WithContext(context.WithValue(req.Context(), logger.Settings.TransactionKey, "from-context"))
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got := out.Header.Get(tt.wantHeader); got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.wantHeader, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPropagateTransactionIDWithoutID(t *testing.T) {
+	t.Parallel()
+
+	req := &http.Request{Method: http.MethodGet}
+	req = req.WithContext(context.Background())
+	middleware.PropagateTransactionID(req)
+
+	if len(req.Header) != 0 {
+		t.Error("expected no header without a transaction id, got:", req.Header)
+	}
+}
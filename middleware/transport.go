@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mikarios/golib/logger"
+)
+
+// ErrCallFailed is logged by Transport for calls that got no response.
+var ErrCallFailed = errors.New("outgoing call failed")
+
+func init() {
+	logger.RegisterSentinel("middleware.ErrCallFailed", ErrCallFailed)
+}
+
+// Transport is an http.RoundTripper that adds the transaction id of the request context to outgoing calls and logs
+// them the way NewLogRequestResponse logs incoming requests, with the same options, so with the same redaction and
+// truncation.
+type Transport struct {
+	base         http.RoundTripper
+	cfg          *logConfig
+	fieldHeaders map[string]string
+}
+
+// NewTransport creates a Transport sending the calls with base, http.DefaultTransport if nil. Matchers of the options
+// apply to the outgoing requests.
+func NewTransport(base http.RoundTripper, opts ...LogOption) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{base: base, cfg: newLogConfig(opts), fieldHeaders: map[string]string{}}
+}
+
+// PropagateField sends the context field named key, see logger.WithFields, in the header name of outgoing calls.
+func (t *Transport) PropagateField(key, header string) *Transport {
+	t.fieldHeaders[key] = header
+
+	return t
+}
+
+// RoundTrip sends a copy of req with the transaction id and the propagated fields as headers, see
+// PropagateTransactionID, and logs it. When the response body is logged, the call is logged once the body is read
+// to its end or closed, otherwise as soon as the response arrives.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	out := req.Clone(ctx)
+
+	PropagateTransactionID(out)
+
+	fields := logger.ContextFields(ctx)
+	for key, header := range t.fieldHeaders {
+		if v, ok := fields[key]; ok && out.Header.Get(header) == "" {
+			out.Header.Set(header, fmt.Sprint(v))
+		}
+	}
+
+	if !t.cfg.logs(out) {
+		return t.base.RoundTrip(out)
+	}
+
+	logBody := t.cfg.logsBody(out)
+
+	reqBody := newBodyCapture(t.cfg.maxBodyBytes, out.Header)
+	if logBody && out.Body != nil && out.Body != http.NoBody {
+		out.Body = &teeReadCloser{ReadCloser: out.Body, capture: reqBody}
+	}
+
+	c := &call{
+		cfg:     t.cfg,
+		ctx:     ctx,
+		req:     out,
+		reqBody: reqBody,
+		start:   time.Now(),
+	}
+
+	res, err := t.base.RoundTrip(out)
+	c.headersTook = time.Since(c.start)
+
+	if err != nil {
+		c.logFailure(err)
+
+		return res, err
+	}
+
+	c.res = res
+	c.resBody = newBodyCapture(t.cfg.maxBodyBytes, res.Header)
+
+	// Bodies of switched protocols are connections, which have to stay as they are.
+	if !logBody || res.Body == nil || res.Body == http.NoBody || res.StatusCode == http.StatusSwitchingProtocols {
+		c.log()
+
+		return res, nil
+	}
+
+	res.Body = &loggedBody{ReadCloser: res.Body, call: c}
+
+	return res, nil
+}
+
+// call is an outgoing call being logged.
+type call struct {
+	cfg         *logConfig
+	ctx         context.Context // nolint:containedctx // the call is logged after RoundTrip returns.
+	req         *http.Request
+	res         *http.Response
+	reqBody     *bodyCapture
+	resBody     *bodyCapture
+	start       time.Time
+	headersTook time.Duration
+	once        sync.Once
+}
+
+func (c *call) request() string {
+	b, _ := json.Marshal(request{
+		URI:     c.cfg.redactor.String(c.cfg.callURI(c.req)),
+		Method:  c.req.Method,
+		Headers: c.cfg.header(c.req.Header),
+		BODY:    c.reqBody.String(c.cfg.redactor),
+	})
+
+	return string(b)
+}
+
+func (c *call) logFailure(err error) {
+	logger.Error(
+		c.ctx,
+		fmt.Errorf("%w : %w", ErrCallFailed, err),
+		"Call failed",
+		"Request:", c.request(),
+		"Execution took:", time.Since(c.start),
+	)
+}
+
+func (c *call) log() {
+	c.once.Do(func() {
+		j, _ := json.Marshal(responseData{
+			Body:    c.resBody.String(c.cfg.redactor),
+			Status:  c.res.StatusCode,
+			Headers: c.cfg.header(c.res.Header),
+		})
+
+		logAtLevel(
+			c.ctx,
+			c.cfg.level(c.res.StatusCode),
+			c.res.StatusCode,
+			"Call finished",
+			"Request:", c.request(),
+			"Response:", string(j),
+			"Headers took:", c.headersTook,
+			"Execution took:", time.Since(c.start),
+		)
+	})
+}
+
+// loggedBody copies the response body to the call and logs the call at its end or when it is closed.
+type loggedBody struct {
+	io.ReadCloser
+	call *call
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = b.call.resBody.Write(p[:n])
+	}
+
+	if errors.Is(err, io.EOF) {
+		b.call.log()
+	}
+
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.call.log()
+
+	return err
+}
+
+// callURI returns the url of an outgoing request with its password masked and without its query string if so
+// configured.
+func (cfg *logConfig) callURI(req *http.Request) string {
+	if !cfg.noQuery {
+		return req.URL.Redacted()
+	}
+
+	u := *req.URL
+	u.RawQuery = ""
+	u.ForceQuery = false
+
+	return u.Redacted()
+}
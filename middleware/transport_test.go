@@ -0,0 +1,158 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mikarios/golib/logger"
+	"github.com/mikarios/golib/middleware"
+)
+
+// callContext returns the context of an outgoing call of t, with its transaction id and a field.
+func callContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx := context.WithValue(context.Background(), logger.Settings.TransactionKey, txID(t))
+
+	return logger.WithFields(ctx, logger.String("userID", "u-1"))
+}
+
+func TestTransportRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan http.Header, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		received <- r.Header.Clone()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"password":"s3cret","padding":"` + strings.Repeat("x", 200) + `"}`))
+	}))
+	defer srv.Close()
+
+	tr := middleware.NewTransport(nil, middleware.WithBodies(), middleware.WithMaxBodyBytes(64)).
+		PropagateField("userID", "X-User-Id")
+	client := &http.Client{Transport: tr}
+
+	body := `{"password":"p4ss","padding":"` + strings.Repeat("y", 200) + `"}`
+
+	req, err := http.NewRequestWithContext(callContext(t), http.MethodPost, srv.URL+"/orders", strings.NewReader(body))
+	if err != nil {
+		t.Fatal("invalid request:", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal("call failed:", err)
+	}
+
+	headers := <-received
+	if headers.Get("txID") != txID(t) || headers.Get("X-User-Id") != "u-1" {
+		t.Error("expected the transaction id and the field as headers, got:", headers)
+	}
+
+	if len(req.Header) != 1 {
+		t.Error("expected the request of the caller to be left alone, got:", req.Header)
+	}
+
+	if entries := logged(t, "Call finished"); len(entries) != 0 {
+		t.Error("expected the call to be logged once its body is read, got:", entries)
+	}
+
+	if _, err = io.ReadAll(res.Body); err != nil {
+		t.Fatal("reading the body failed:", err)
+	}
+
+	if err = res.Body.Close(); err != nil {
+		t.Fatal("closing the body failed:", err)
+	}
+
+	entries := logged(t, "Call finished")
+	if len(entries) != 1 {
+		t.Fatal("expected the call to be logged once, got:", entries)
+	}
+
+	msg := entries[0].Message
+	for _, secret := range []string{"p4ss", "s3cret"} {
+		if strings.Contains(msg, secret) {
+			t.Errorf("expected %s to be redacted in %s", secret, msg)
+		}
+	}
+
+	if strings.Count(msg, "[REDACTED]") != 2 || strings.Count(msg, "[truncated") != 2 {
+		t.Error("expected both bodies redacted and truncated, got:", msg)
+	}
+}
+
+func TestTransportWithoutBodies(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: middleware.NewTransport(nil)}
+
+	req, err := http.NewRequestWithContext(callContext(t), http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal("invalid request:", err)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal("call failed:", err)
+	}
+
+	entries := logged(t, "Call finished")
+	if len(entries) != 1 || entries[0].Level != logger.LogLevels.WARNING {
+		t.Error("expected the call to be logged as soon as it is answered, got:", entries)
+	}
+
+	_ = res.Body.Close()
+
+	if entries = logged(t, "Call finished"); len(entries) != 1 {
+		t.Error("expected no second entry on close, got:", entries)
+	}
+}
+
+func TestTransportCallFailed(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.Close()
+
+	client := &http.Client{Transport: middleware.NewTransport(nil)}
+
+	req, err := http.NewRequestWithContext(callContext(t), http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal("invalid request:", err)
+	}
+
+	res, err := client.Do(req)
+	if err == nil {
+		_ = res.Body.Close()
+
+		t.Fatal("expected the call to fail")
+	}
+
+	entries := logged(t, "Call failed")
+	if len(entries) != 1 || entries[0].Level != logger.LogLevels.ERROR ||
+		!errors.Is(entries[0].Error, middleware.ErrCallFailed) {
+		t.Error("expected the failure to be logged with ErrCallFailed, got:", entries)
+	}
+
+	sentinels, _ := entries[0].Fields["error_sentinels"].([]string)
+	if !reflect.DeepEqual(sentinels, []string{"middleware.ErrCallFailed"}) {
+		t.Error("expected the failure to be grouped under middleware.ErrCallFailed, got:", entries[0].Fields)
+	}
+}
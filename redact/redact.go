@@ -0,0 +1,481 @@
+// Package redact removes sensitive data such as credentials, tokens and card numbers from headers, json and form
+// bodies and free text before they get logged. A Redactor is built by chaining its methods:
+/*
+	redactor := redact.New().
+		Headers("Authorization", "Cookie").
+		Fields("password", "user.card.number", "items.*.token").
+		Patterns(redact.CardNumberPattern).
+		Strategy(redact.Hash)
+*/
+package redact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Strategy decides what happens to the data that has to be redacted.
+type Strategy int
+
+const (
+	// Mask replaces the data with the replacement text, DefaultReplacement unless set.
+	Mask Strategy = iota
+	// Hash replaces the data with the first 16 hex characters of its sha256, so that equal values can still be
+	// correlated.
+	Hash
+	// Drop removes the data. Headers and json fields are removed entirely, matches in text are deleted.
+	Drop
+)
+
+// DefaultReplacement is the text Mask uses unless a Replacement is set.
+const DefaultReplacement = "[REDACTED]"
+
+const hashLength = 16
+
+var (
+	arrayIndexReplacer = strings.NewReplacer("[", ".", "]", "")
+	// CardNumberPattern matches payment card numbers of 13 to 19 digits, optionally separated by spaces or dashes.
+	// Redactors only redact the matches that pass the Luhn check, so that ids and timestamps of that length are kept.
+	CardNumberPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	// BearerTokenPattern matches bearer tokens as found in Authorization headers.
+	BearerTokenPattern = regexp.MustCompile(`(?i)bearer\s+[a-z0-9\-._~+/]+=*`)
+
+	errTrailingData = errors.New("trailing data after json document")
+)
+
+type pattern struct {
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+// Redactor holds the rules that decide what gets redacted. A nil Redactor redacts nothing.
+type Redactor struct {
+	headers map[string]struct{}
+	fields  [][]string
+	// loose matches the "name": pairs of the field rules in text that is not valid json, see looseFields.
+	loose       *regexp.Regexp
+	patterns    []pattern
+	strategy    Strategy
+	replacement string
+}
+
+// New creates a Redactor without any rule that masks what it redacts.
+func New() *Redactor {
+	return &Redactor{headers: make(map[string]struct{}), replacement: DefaultReplacement}
+}
+
+// Default creates a Redactor that masks the usual credential headers, the usual credential json fields, card numbers
+// and bearer tokens.
+func Default() *Redactor {
+	return New().
+		Headers("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key").
+		Fields("password", "passwd", "secret", "token", "access_token", "refresh_token", "card_number", "cvv").
+		Patterns(CardNumberPattern, BearerTokenPattern)
+}
+
+// Headers adds the names of the headers whose values are redacted. Names are case-insensitive.
+func (r *Redactor) Headers(names ...string) *Redactor {
+	for _, name := range names {
+		r.headers[http.CanonicalHeaderKey(name)] = struct{}{}
+	}
+
+	return r
+}
+
+// Fields adds the json and form fields whose values are redacted. A name without dots, e.g. "password", matches the
+// field at any depth. A dotted path, e.g. "user.card.number", matches from the root of the document. Array elements
+// are addressed by their index and a "*" segment matches any field or index, so "items.*.token" and "items[*].token"
+// are the same. Names are case-insensitive and a leading "$." is ignored.
+func (r *Redactor) Fields(paths ...string) *Redactor {
+	for _, path := range paths {
+		path = strings.TrimPrefix(strings.ToLower(path), "$.")
+		path = arrayIndexReplacer.Replace(path)
+
+		if path == "" {
+			continue
+		}
+
+		r.fields = append(r.fields, strings.Split(path, "."))
+	}
+
+	r.loose = r.loosePattern()
+
+	return r
+}
+
+// Patterns adds regular expressions whose matches are redacted from free text and from json string values. Matches of
+// CardNumberPattern are validated with Luhn.
+func (r *Redactor) Patterns(patterns ...*regexp.Regexp) *Redactor {
+	for _, re := range patterns {
+		var valid func(string) bool
+		if re == CardNumberPattern {
+			valid = Luhn
+		}
+
+		r.patterns = append(r.patterns, pattern{re: re, valid: valid})
+	}
+
+	return r
+}
+
+// ValidatedPattern adds a regular expression whose matches are redacted when valid returns true for them, e.g. to
+// check the checksum of an id.
+func (r *Redactor) ValidatedPattern(re *regexp.Regexp, valid func(match string) bool) *Redactor {
+	r.patterns = append(r.patterns, pattern{re: re, valid: valid})
+
+	return r
+}
+
+// Luhn reports whether the digits of s pass the Luhn checksum of payment card numbers. Spaces and dashes are
+// ignored, any other character fails the check.
+func Luhn(s string) bool {
+	sum, digits := 0, 0
+
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+
+		if c < '0' || c > '9' {
+			return false
+		}
+
+		d := int(c - '0')
+		if digits%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		digits++
+	}
+
+	return digits > 0 && sum%10 == 0
+}
+
+// Strategy sets what happens to the data that has to be redacted.
+func (r *Redactor) Strategy(strategy Strategy) *Redactor {
+	r.strategy = strategy
+
+	return r
+}
+
+// Replacement sets the text Mask replaces data with.
+func (r *Redactor) Replacement(replacement string) *Redactor {
+	r.replacement = replacement
+
+	return r
+}
+
+// Header returns a copy of header with the values of the denied headers redacted.
+func (r *Redactor) Header(header http.Header) http.Header {
+	if header == nil {
+		return nil
+	}
+
+	redacted := header.Clone()
+	if r == nil {
+		return redacted
+	}
+
+	for name := range redacted {
+		if _, ok := r.headers[http.CanonicalHeaderKey(name)]; !ok {
+			continue
+		}
+
+		if r.strategy == Drop {
+			delete(redacted, name)
+
+			continue
+		}
+
+		for i, value := range redacted[name] {
+			redacted[name][i] = r.replace(value)
+		}
+	}
+
+	return redacted
+}
+
+// String redacts the matches of the patterns from s.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.valid != nil && !p.valid(match) {
+				return match
+			}
+
+			return r.replace(match)
+		})
+	}
+
+	return s
+}
+
+// JSON redacts the fields and the pattern matches of a json document. Documents that are not valid json, e.g. bodies
+// cut short before logging, are redacted as free text, in which the values of "name": pairs are redacted too for the
+// last segment of every field rule, since their path cannot be known. Numbers are kept as they are written.
+func (r *Redactor) JSON(body []byte) []byte {
+	if r == nil || len(body) == 0 {
+		return body
+	}
+
+	document, err := decodeJSON(body)
+	if err != nil {
+		return []byte(r.String(r.looseFields(string(body))))
+	}
+
+	redacted, err := json.Marshal(r.Value(document))
+	if err != nil {
+		return []byte(r.String(r.looseFields(string(body))))
+	}
+
+	return redacted
+}
+
+// Form redacts an application/x-www-form-urlencoded body: the values of the fields matched by the field rules, whose
+// names are matched like json paths with "user[card][number]" being "user.card.number", and the pattern matches of the
+// other values. The order of the fields is kept and so are the fields that cannot be decoded, e.g. the last one of a
+// body cut short, which are redacted as free text.
+func (r *Redactor) Form(body []byte) []byte {
+	if r == nil || len(body) == 0 {
+		return body
+	}
+
+	pairs := strings.Split(string(body), "&")
+	redacted := make([]string, 0, len(pairs))
+
+	for _, pair := range pairs {
+		rawName, _, _ := strings.Cut(pair, "=")
+
+		values, err := url.ParseQuery(pair)
+		if err != nil || len(values) != 1 {
+			redacted = append(redacted, r.String(pair))
+
+			continue
+		}
+
+		for name, value := range values {
+			path := strings.Split(arrayIndexReplacer.Replace(strings.ToLower(name)), ".")
+
+			switch {
+			case r.matches(path) && r.strategy == Drop:
+			case r.matches(path):
+				redacted = append(redacted, rawName+"="+url.QueryEscape(r.replace(value[0])))
+			default:
+				if v := r.String(value[0]); v != value[0] {
+					pair = rawName + "=" + url.QueryEscape(v)
+				}
+
+				redacted = append(redacted, pair)
+			}
+		}
+	}
+
+	return []byte(strings.Join(redacted, "&"))
+}
+
+// decodeJSON decodes a single json document, with its numbers as json.Number so that they keep their precision.
+func decodeJSON(body []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var document interface{}
+	if err := dec.Decode(&document); err != nil {
+		return nil, fmt.Errorf("could not decode json: %w", err)
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errTrailingData
+	}
+
+	return document, nil
+}
+
+// loosePattern compiles the pattern of looseFields for the field rules, nil if no rule has a name to look for.
+func (r *Redactor) loosePattern() *regexp.Regexp {
+	names := make([]string, 0, len(r.fields))
+
+	for _, rule := range r.fields {
+		if name := rule[len(rule)-1]; name != "*" {
+			names = append(names, regexp.QuoteMeta(name))
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	return regexp.MustCompile(`(?i)("(?:` + strings.Join(names, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+}
+
+// looseFields redacts the values following the quoted names of the field rules in s. String values may miss their
+// closing quote when s is cut short.
+func (r *Redactor) looseFields(s string) string {
+	pairs := r.loose
+	if pairs == nil {
+		return s
+	}
+
+	return pairs.ReplaceAllStringFunc(s, func(pair string) string {
+		m := pairs.FindStringSubmatch(pair)
+
+		return m[1] + strconv.Quote(r.replace(strings.Trim(m[2], `"`)))
+	})
+}
+
+// Value redacts a decoded json value or a logged one: maps with string keys, slices, strings and values nested in
+// them. Maps and slices of other types than the ones of decoded json, e.g. map[string]string or []string, are returned
+// as map[string]interface{} and []interface{}, while http.Header values get the header rules as well and keep their
+// type. Other types, structs, byte slices and types with their own json encoding included, are returned as they are.
+func (r *Redactor) Value(value interface{}) interface{} {
+	if r == nil {
+		return value
+	}
+
+	return r.value(value, nil)
+}
+
+func (r *Redactor) value(value interface{}, path []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+
+		for key, fieldValue := range v {
+			fieldPath := append(path[:len(path):len(path)], strings.ToLower(key))
+
+			if r.matches(fieldPath) {
+				if r.strategy != Drop {
+					redacted[key] = r.replace(fmt.Sprint(fieldValue))
+				}
+
+				continue
+			}
+
+			redacted[key] = r.value(fieldValue, fieldPath)
+		}
+
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i := range v {
+			redacted[i] = r.value(v[i], append(path[:len(path):len(path)], strconv.Itoa(i)))
+		}
+
+		return redacted
+	case string:
+		return r.String(v)
+	case http.Header:
+		redacted := r.Header(v)
+		for name, values := range redacted {
+			for i := range values {
+				values[i] = r.String(values[i])
+			}
+
+			redacted[name] = values
+		}
+
+		return redacted
+	default:
+		return r.reflectValue(value, path)
+	}
+}
+
+// reflectValue redacts the maps with string keys and the slices of types other than the ones of decoded json, by
+// copying them to map[string]interface{} and []interface{}.
+func (r *Redactor) reflectValue(value interface{}, path []string) interface{} {
+	if _, ok := value.(json.Marshaler); ok {
+		return value
+	}
+
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() { // nolint:exhaustive // only maps and slices hold other values.
+	case reflect.Map:
+		if rv.IsNil() || rv.Type().Key().Kind() != reflect.String {
+			return value
+		}
+
+		m := make(map[string]interface{}, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+
+		return r.value(m, path)
+	case reflect.Slice, reflect.Array:
+		if (rv.Kind() == reflect.Slice && rv.IsNil()) || rv.Type().Elem().Kind() == reflect.Uint8 {
+			return value
+		}
+
+		s := make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = rv.Index(i).Interface()
+		}
+
+		return r.value(s, path)
+	default:
+		return value
+	}
+}
+
+func (r *Redactor) matches(path []string) bool {
+	for _, rule := range r.fields {
+		if len(rule) == 1 {
+			if rule[0] == path[len(path)-1] {
+				return true
+			}
+
+			continue
+		}
+
+		if len(rule) != len(path) {
+			continue
+		}
+
+		matched := true
+
+		for i := range rule {
+			if rule[i] != "*" && rule[i] != path[i] {
+				matched = false
+
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Redactor) replace(value string) string {
+	switch r.strategy {
+	case Hash:
+		sum := sha256.Sum256([]byte(value))
+
+		return hex.EncodeToString(sum[:])[:hashLength]
+	case Drop:
+		return ""
+	default:
+		return r.replacement
+	}
+}
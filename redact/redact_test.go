@@ -0,0 +1,315 @@
+package redact_test
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/mikarios/golib/redact"
+)
+
+func TestHeader(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{
+		"Authorization": {"Bearer abc"},
+		"Cookie":        {"a=1", "b=2"},
+		"Accept":        {"application/json"},
+	}
+
+	tests := []struct {
+		name     string
+		redactor *redact.Redactor
+		want     http.Header
+	}{
+		{
+			name:     "mask",
+			redactor: redact.New().Headers("authorization", "COOKIE"),
+			want: http.Header{
+				"Authorization": {redact.DefaultReplacement},
+				"Cookie":        {redact.DefaultReplacement, redact.DefaultReplacement},
+				"Accept":        {"application/json"},
+			},
+		},
+		{
+			name:     "drop",
+			redactor: redact.New().Headers("Authorization", "Cookie").Strategy(redact.Drop),
+			want:     http.Header{"Accept": {"application/json"}},
+		},
+		{
+			name:     "hash",
+			redactor: redact.New().Headers("Authorization").Strategy(redact.Hash),
+			want: http.Header{
+				"Authorization": {"c355dce96c161288"},
+				"Cookie":        {"a=1", "b=2"},
+				"Accept":        {"application/json"},
+			},
+		},
+		{
+			name:     "nil redactor",
+			redactor: nil,
+			want:     header,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.redactor.Header(header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Header() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if header.Get("Authorization") != "Bearer abc" {
+		t.Error("expected original header to be untouched")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	body := `{"user":{"name":"john","password":"p4ss","card":{"number":"4111 1111 1111 1111"}},` +
+		`"items":[{"token":"t1","id":1},{"token":"t2","id":2}],"note":"paid with 4111-1111-1111-1111"}`
+
+	tests := []struct {
+		name     string
+		redactor *redact.Redactor
+		body     string
+		want     string
+	}{
+		{
+			name:     "fields at any depth and paths",
+			redactor: redact.New().Fields("password", "$.user.card.number", "items[1].token"),
+			body:     body,
+			want: `{"items":[{"id":1,"token":"t1"},{"id":2,"token":"[REDACTED]"}],` +
+				`"note":"paid with 4111-1111-1111-1111",` +
+				`"user":{"card":{"number":"[REDACTED]"},"name":"john","password":"[REDACTED]"}}`,
+		},
+		{
+			name:     "wildcard and drop",
+			redactor: redact.New().Fields("items.*.token", "Password").Strategy(redact.Drop),
+			body:     body,
+			want: `{"items":[{"id":1},{"id":2}],"note":"paid with 4111-1111-1111-1111",` +
+				`"user":{"card":{"number":"4111 1111 1111 1111"},"name":"john"}}`,
+		},
+		{
+			name:     "patterns in string values",
+			redactor: redact.New().Patterns(redact.CardNumberPattern).Replacement("****"),
+			body:     body,
+			want: `{"items":[{"id":1,"token":"t1"},{"id":2,"token":"t2"}],"note":"paid with ****",` +
+				`"user":{"card":{"number":"****"},"name":"john","password":"p4ss"}}`,
+		},
+		{
+			name:     "invalid json is redacted as text",
+			redactor: redact.New().Patterns(regexp.MustCompile(`secret=\w+`)),
+			body:     `a=1&secret=abc`,
+			want:     `a=1&[REDACTED]`,
+		},
+		{
+			name:     "fields of cut documents",
+			redactor: redact.New().Fields("password", "user.pin", "items.*"),
+			body:     `{"user":{"password": "p4\"ss", "pin":1234, "name":"john"},"token":"abc`,
+			want:     `{"user":{"password": "[REDACTED]", "pin":"[REDACTED]", "name":"john"},"token":"abc`,
+		},
+		{
+			name:     "unterminated value of cut documents",
+			redactor: redact.New().Fields("token").Strategy(redact.Hash),
+			body:     `{"id":1,"token":"abc`,
+			want:     `{"id":1,"token":"ba7816bf8f01cfea"`,
+		},
+		{
+			name:     "numbers keep their precision",
+			redactor: redact.New().Fields("token"),
+			body:     `{"id":12345678901234567890,"price":0.1,"token":"abc"}`,
+			want:     `{"id":12345678901234567890,"price":0.1,"token":"[REDACTED]"}`,
+		},
+		{
+			name:     "trailing data is redacted as text",
+			redactor: redact.New().Fields("token"),
+			body:     `{"token":"abc"} {"token":"def"}`,
+			want:     `{"token":"[REDACTED]"} {"token":"[REDACTED]"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := string(tt.redactor.JSON([]byte(tt.body))); got != tt.want {
+				t.Errorf("JSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		redactor *redact.Redactor
+		body     string
+		want     string
+	}{
+		{
+			name:     "login form",
+			redactor: redact.Default(),
+			body:     "username=bob&password=hunter2&token=abc",
+			want:     "username=bob&password=%5BREDACTED%5D&token=%5BREDACTED%5D",
+		},
+		{
+			name:     "escaped names and patterns in values",
+			redactor: redact.Default(),
+			body:     "Pass%77ord=x&note=card+4111+1111+1111+1111&empty&=",
+			want:     "Pass%77ord=%5BREDACTED%5D&note=card+%5BREDACTED%5D&empty&=",
+		},
+		{
+			name:     "paths and drop",
+			redactor: redact.New().Fields("user.card.number").Strategy(redact.Drop),
+			body:     "user[name]=bob&user[card][number]=4111111111111111&number=1",
+			want:     "user[name]=bob&number=1",
+		},
+		{
+			name:     "body cut short",
+			redactor: redact.Default(),
+			body:     "password=hunter2&note=100%2",
+			want:     "password=%5BREDACTED%5D&note=100%2",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := string(tt.redactor.Form([]byte(tt.body))); got != tt.want {
+				t.Errorf("Form() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type credentials map[string]string
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	redactor := redact.Default()
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{
+			name:  "map of strings",
+			value: map[string]string{"password": "hunter2", "user": "bob"},
+			want:  map[string]interface{}{"password": "[REDACTED]", "user": "bob"},
+		},
+		{
+			name:  "named map",
+			value: credentials{"token": "tok123"},
+			want:  map[string]interface{}{"token": "[REDACTED]"},
+		},
+		{
+			name:  "nested typed values",
+			value: map[string]interface{}{"users": []map[string]string{{"secret": "s1", "name": "john"}}},
+			want: map[string]interface{}{
+				"users": []interface{}{map[string]interface{}{"secret": "[REDACTED]", "name": "john"}},
+			},
+		},
+		{
+			name:  "slice of strings",
+			value: []string{"Bearer abc.def", "plain"},
+			want:  []interface{}{"[REDACTED]", "plain"},
+		},
+		{
+			name:  "header",
+			value: http.Header{"Authorization": {"Basic dXNlcjpwYXNz"}, "X-Forwarded": {"Bearer abc"}, "Accept": {"*/*"}},
+			want:  http.Header{"Authorization": {"[REDACTED]"}, "X-Forwarded": {"[REDACTED]"}, "Accept": {"*/*"}},
+		},
+		{name: "bytes", value: []byte("password"), want: []byte("password")},
+		{name: "map with other keys", value: map[int]string{1: "Bearer abc"}, want: map[int]string{1: "Bearer abc"}},
+		{name: "struct", value: struct{ Password string }{"p4ss"}, want: struct{ Password string }{"p4ss"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := redactor.Value(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Value() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	redactor := redact.Default()
+
+	got := redactor.String("calling with Bearer eyJhbGciOi.abc and card 4111111111111111")
+	want := "calling with [REDACTED] and card [REDACTED]"
+
+	if got != want {
+		t.Errorf("String() = %v, want %v", got, want)
+	}
+
+	if got = redactor.String("order 12345 costs 10"); got != "order 12345 costs 10" {
+		t.Errorf("String() redacted short numbers: %v", got)
+	}
+
+	for _, s := range []string{"order 1700000000000000000 created", "GET /orders/4111111111111112"} {
+		if got = redactor.String(s); got != s {
+			t.Errorf("String() redacted numbers that fail the Luhn check: %v", got)
+		}
+	}
+
+	custom := redact.New().ValidatedPattern(regexp.MustCompile(`id-\d+`), func(match string) bool {
+		return match != "id-0"
+	})
+
+	if got = custom.String("id-0 id-1"); got != "id-0 [REDACTED]" {
+		t.Errorf("String() = %v, want only the valid match redacted", got)
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		number string
+		want   bool
+	}{
+		{number: "4111111111111111", want: true},
+		{number: "4111 1111-1111 1111", want: true},
+		{number: "5500005555555559", want: true},
+		{number: "4111111111111112", want: false},
+		{number: "1700000000000000000", want: false},
+		{number: "4111a11111111111", want: false},
+		{number: "", want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.number, func(t *testing.T) {
+			t.Parallel()
+
+			if got := redact.Luhn(tt.number); got != tt.want {
+				t.Errorf("Luhn(%q) = %v, want %v", tt.number, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Package stackerr creates errors that carry the stack trace of the place they were created. The logger reports
+// that trace instead of the place the error was logged from.
+package stackerr
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+const maxDepth = 64
+
+type withStack struct {
+	err   error
+	stack []uintptr
+}
+
+// New creates an error with the given message and the stack trace of the caller.
+func New(message string) error {
+	return &withStack{err: errors.New(message), stack: callers()} // nolint:goerr113 // dynamic message on purpose.
+}
+
+// Errorf formats an error the same way fmt.Errorf does, %w included, and records the stack trace of the caller.
+func Errorf(format string, args ...interface{}) error {
+	return &withStack{err: fmt.Errorf(format, args...), stack: callers()} // nolint:goerr113 // wraps fmt.Errorf.
+}
+
+// Wrap records the stack trace of the caller on err. If err is nil it returns nil and if err already carries a stack
+// trace it is returned as it is, so that the trace always points to the origin of the error.
+func Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var traced *withStack
+	if errors.As(err, &traced) {
+		return err
+	}
+
+	return &withStack{err: err, stack: callers()}
+}
+
+// Error returns the message of the wrapped error.
+func (e *withStack) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error so that errors.Is and errors.As keep working.
+func (e *withStack) Unwrap() error {
+	return e.err
+}
+
+// StackTrace returns the program counters of the stack at the time the error was created.
+func (e *withStack) StackTrace() []uintptr {
+	return e.stack
+}
+
+func callers() []uintptr {
+	pcs := make([]uintptr, maxDepth)
+	// Skip runtime.Callers, callers and the exported function that created the error.
+	n := runtime.Callers(3, pcs)
+
+	return pcs[:n]
+}
@@ -0,0 +1,78 @@
+package stackerr_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mikarios/golib/stackerr"
+)
+
+var errBase = errors.New("base")
+
+type stackTracer interface {
+	StackTrace() []uintptr
+}
+
+func firstFunction(t *testing.T, err error) string {
+	t.Helper()
+
+	var traced stackTracer
+	if !errors.As(err, &traced) {
+		t.Fatal("expected error to carry a stack trace")
+	}
+
+	frame, _ := runtime.CallersFrames(traced.StackTrace()).Next()
+
+	return frame.Function
+}
+
+func TestStackTrace(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "new", err: stackerr.New("new")},
+		{name: "errorf", err: stackerr.Errorf("errorf: %w", errBase)},
+		{name: "wrap", err: stackerr.Wrap(errBase)},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if fn := firstFunction(t, tt.err); !strings.HasSuffix(fn, "stackerr_test.TestStackTrace") {
+				t.Errorf("expected trace to start at the caller, got %v", fn)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+
+	if stackerr.Wrap(nil) != nil {
+		t.Error("expected nil when wrapping nil")
+	}
+
+	withContext := fmt.Errorf("context: %w", stackerr.Errorf("origin: %w", errBase))
+	wrapped := stackerr.Wrap(withContext)
+
+	if !errors.Is(wrapped, errBase) {
+		t.Error("expected wrapped error to match its cause")
+	}
+
+	if wrapped.Error() != "context: origin: base" {
+		t.Error("unexpected message", wrapped.Error())
+	}
+
+	if wrapped != withContext { // nolint:errorlint // checking identity on purpose.
+		t.Error("expected Wrap to keep the trace of the origin")
+	}
+}